@@ -0,0 +1,39 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// JournalAvailable reports whether the systemd logger's socket exists
+// at /dev/log.
+func JournalAvailable() bool {
+	return socketAvailable("/dev/log")
+}
+
+// socketAvailable reports whether path is a socket.
+func socketAvailable(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode()&os.ModeSocket != 0
+}
+
+// WithAutoSink makes the logger write to the systemd logger when its
+// socket exists and to os.Stderr otherwise. On stderr each message is
+// a plain line like "WARNING [prefix] message", which is easier to
+// read in a terminal. The choice is made once, when the logger is
+// created.
+func WithAutoSink() Option {
+	return func(sdl *Sysdlog) {
+		sdl.autoSink = true
+	}
+}
+
+// formatText builds the line written to the text sink.
+func (sdl *Sysdlog) formatText(s Severity, prefix, tokens, m string) []byte {
+	return []byte(fmt.Sprintf("%-7s %s%s%s\n", strings.ToUpper(s.name()), prefix, tokens, m))
+}
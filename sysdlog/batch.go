@@ -0,0 +1,132 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// Batch groups related messages under a shared correlation id. Every
+// message logged through a Batch starts with "correlation_id=<id> ",
+// so the lines from one request can be found together in the journal.
+// It is not a transaction; each message is still sent on its own
+// through the parent logger.
+type Batch struct {
+	sdl *Sysdlog
+	id  string
+}
+
+// Begin starts a new Batch with a freshly generated correlation id.
+func (sdl *Sysdlog) Begin() *Batch {
+	id, err := newID(8)
+	if err != nil {
+		panic("sysdlog: generating correlation id: " + err.Error())
+	}
+
+	return &Batch{sdl: sdl, id: id}
+}
+
+// newID returns n random bytes encoded as hex.
+func newID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// ID returns the batch's correlation id.
+func (b *Batch) ID() string {
+	return b.id
+}
+
+// log writes m with the correlation id at severity s.
+func (b *Batch) log(s Severity, m string) error {
+	_, err := b.sdl.writeRetry(s, "correlation_id="+b.id+" "+m)
+	return err
+}
+
+// Emerg logs a message with severity LOG_EMERG.
+func (b *Batch) Emerg(m string) error {
+	return b.log(LOG_EMERG, m)
+}
+
+// Alert logs a message with severity LOG_ALERT.
+func (b *Batch) Alert(m string) error {
+	return b.log(LOG_ALERT, m)
+}
+
+// Crit logs a message with severity LOG_CRIT.
+func (b *Batch) Crit(m string) error {
+	return b.log(LOG_CRIT, m)
+}
+
+// Err logs a message with severity LOG_ERR.
+func (b *Batch) Err(m string) error {
+	return b.log(LOG_ERR, m)
+}
+
+// Warning logs a message with severity LOG_WARNING.
+func (b *Batch) Warning(m string) error {
+	return b.log(LOG_WARNING, m)
+}
+
+// Notice logs a message with severity LOG_NOTICE.
+func (b *Batch) Notice(m string) error {
+	return b.log(LOG_NOTICE, m)
+}
+
+// Info logs a message with severity LOG_INFO.
+func (b *Batch) Info(m string) error {
+	return b.log(LOG_INFO, m)
+}
+
+// Debug logs a message with severity LOG_DEBUG.
+func (b *Batch) Debug(m string) error {
+	return b.log(LOG_DEBUG, m)
+}
+
+// Emergf logs a message with severity LOG_EMERG.
+func (b *Batch) Emergf(format string, v ...interface{}) error {
+	return b.log(LOG_EMERG, fmt.Sprintf(format, v...))
+}
+
+// Alertf logs a message with severity LOG_ALERT.
+func (b *Batch) Alertf(format string, v ...interface{}) error {
+	return b.log(LOG_ALERT, fmt.Sprintf(format, v...))
+}
+
+// Critf logs a message with severity LOG_CRIT.
+func (b *Batch) Critf(format string, v ...interface{}) error {
+	return b.log(LOG_CRIT, fmt.Sprintf(format, v...))
+}
+
+// Errf logs a message with severity LOG_ERR.
+func (b *Batch) Errf(format string, v ...interface{}) error {
+	return b.log(LOG_ERR, fmt.Sprintf(format, v...))
+}
+
+// Warningf logs a message with severity LOG_WARNING.
+func (b *Batch) Warningf(format string, v ...interface{}) error {
+	return b.log(LOG_WARNING, fmt.Sprintf(format, v...))
+}
+
+// Noticef logs a message with severity LOG_NOTICE.
+func (b *Batch) Noticef(format string, v ...interface{}) error {
+	return b.log(LOG_NOTICE, fmt.Sprintf(format, v...))
+}
+
+// Infof logs a message with severity LOG_INFO.
+func (b *Batch) Infof(format string, v ...interface{}) error {
+	return b.log(LOG_INFO, fmt.Sprintf(format, v...))
+}
+
+// Debugf logs a message with severity LOG_DEBUG.
+func (b *Batch) Debugf(format string, v ...interface{}) error {
+	return b.log(LOG_DEBUG, fmt.Sprintf(format, v...))
+}
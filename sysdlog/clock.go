@@ -0,0 +1,33 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "time"
+
+// WithClock replaces the time source the logger reads the time from,
+// such as for the retry budget, rate limiting, dedupe and coalesce
+// windows, severity boosts, the ring buffer and spool file names. It
+// defaults to time.Now. This is mostly useful for making tests
+// deterministic. A clock that can only be read can't be waited on, so
+// socket deadlines, the reconnect and connect retry delays, the self
+// healing interval and the real time limit of a coalesce window still
+// use real time.
+func WithClock(clock func() time.Time) Option {
+	return func(sdl *Sysdlog) {
+		sdl.clock = clock
+	}
+}
+
+// now returns the current time according to the logger's clock.
+func (sdl *Sysdlog) now() time.Time {
+	return sdl.clock()
+}
+
+// since returns the time elapsed since t according to the logger's
+// clock. When both times come from time.Now the monotonic clock is
+// used, so wall clock changes don't affect the result.
+func (sdl *Sysdlog) since(t time.Time) time.Duration {
+	return sdl.clock().Sub(t)
+}
@@ -0,0 +1,123 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// errCoalesceStream is returned when ConnAuto finds a stream socket
+// while coalescing, where the joined entries would be split apart.
+var errCoalesceStream = errors.New("sysdlog: coalesced entries can't be sent on a stream socket")
+
+// WithCoalesceWindow holds messages for up to d and then sends all the
+// messages of each severity as one entry, joined with newlines, to cut
+// down the number of journal entries for bursts of output. The window
+// starts with the first message held and ends once d has passed on
+// the logger's clock, checked when the next message arrives, or after
+// d of real time at the latest. A change of prefix, FlushSync and
+// Close end the window early. Errors from sending a held entry are
+// only reported in Stats, since the calls that logged its messages
+// have already returned. WithLineSeparatorReplacement applies to the
+// joined entry, so it turns the joins into separators too. Stream
+// sockets and the fallback writer split messages at newlines, so
+// coalescing can't be used with them unless the replacement removes
+// newlines. Messages still held when the process exits are lost, so
+// programs that may be stopped by a signal should use FlushOnSignal.
+func WithCoalesceWindow(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.coalesce = &coalescer{window: d}
+	}
+}
+
+// coalescer holds the messages of the current window. It is protected
+// by the logger's lock.
+type coalescer struct {
+	window time.Duration
+	start  time.Time
+	prefix string
+	order  []Severity
+	lines  map[Severity][]string
+	timer  *time.Timer
+	gen    int
+}
+
+// hold adds a message to the current window, starting one if needed
+// and sending the previous one first if it has ended. The caller must
+// hold the lock.
+func (sdl *Sysdlog) hold(s Severity, prefix, m string) {
+	c := sdl.coalesce
+	if len(c.order) > 0 && (prefix != c.prefix || sdl.since(c.start) >= c.window) {
+		sdl.flushHeld()
+	}
+
+	if len(c.order) == 0 {
+		c.start, c.prefix = sdl.now(), prefix
+		c.lines = make(map[Severity][]string)
+		c.gen++
+		gen := c.gen
+		c.timer = time.AfterFunc(c.window, func() { sdl.windowEnded(gen) })
+	}
+
+	if _, ok := c.lines[s]; !ok {
+		c.order = append(c.order, s)
+	}
+	c.lines[s] = append(c.lines[s], strings.TrimSuffix(m, "\n"))
+}
+
+// flushHeld sends one entry for each severity held in the current
+// window, in the order the severities first appeared. The caller must
+// hold the lock.
+func (sdl *Sysdlog) flushHeld() {
+	c := sdl.coalesce
+	if c == nil || len(c.order) == 0 {
+		return
+	}
+
+	c.timer.Stop()
+	for _, s := range c.order {
+		sdl.deliver(s, sdl.format(s, c.prefix, strings.Join(c.lines[s], "\n")))
+	}
+	c.order, c.lines, c.timer = nil, nil, nil
+}
+
+// windowEnded sends what is held once the real time of window gen is
+// up, unless that window has already been sent.
+func (sdl *Sysdlog) windowEnded(gen int) {
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.coalesce.gen == gen {
+		sdl.flushHeld()
+	}
+}
+
+// joinSplits reports whether a joined entry would be split apart
+// where it may be written, because newlines frame messages there and
+// aren't replaced.
+func (sdl *Sysdlog) joinSplits() bool {
+	if !sdl.keepsNewlines() {
+		return false
+	}
+
+	switch {
+	case sdl.fallback != nil:
+		return true
+	case !sdl.isUnixNetwork():
+		return strings.HasPrefix(sdl.network, "tcp")
+	case sdl.connType == ConnAuto:
+		return sdl.network == "unix"
+	}
+
+	return sdl.connType == ConnStream
+}
+
+// keepsNewlines reports whether newlines are left in messages rather
+// than replaced by WithLineSeparatorReplacement.
+func (sdl *Sysdlog) keepsNewlines() bool {
+	return sdl.replacer == nil || strings.Contains(sdl.replacer.Replace("\n"), "\n")
+}
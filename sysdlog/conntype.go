@@ -0,0 +1,134 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrSocketMissing is returned when the path of a unix socket doesn't
+// exist, which usually means the systemd logger's socket wasn't
+// mounted into the container.
+var ErrSocketMissing = errors.New("sysdlog: socket does not exist")
+
+// ErrNotSocket is returned when the path of a unix socket exists but
+// isn't a socket, like a /dev/log that was created as a regular file.
+var ErrNotSocket = errors.New("sysdlog: path is not a socket")
+
+// ConnType selects the kind of unix socket the logger connects to.
+type ConnType int
+
+const (
+	// ConnAuto connects to a datagram socket, and retries as a
+	// stream socket if the socket turns out to be one. This is the
+	// default.
+	ConnAuto ConnType = iota
+
+	// ConnDatagram only connects to a datagram socket.
+	ConnDatagram
+
+	// ConnStream only connects to a stream socket. Each message is
+	// terminated by a newline so the other end can split them.
+	ConnStream
+)
+
+// WithConnType forces the kind of unix socket the logger connects
+// to. It has no effect on network connections made with Dial, such as
+// TCP or UDP.
+func WithConnType(t ConnType) Option {
+	return func(sdl *Sysdlog) {
+		sdl.connType = t
+	}
+}
+
+// dial connects to the socket. For unix sockets, a failed dial is
+// explained by ErrSocketMissing or ErrNotSocket when the path is the
+// problem.
+func (sdl *Sysdlog) dial(deadline time.Time) (net.Conn, error) {
+	d := net.Dialer{Deadline: deadline}
+	if !sdl.isUnixNetwork() {
+		return d.Dial(sdl.network, sdl.raddr)
+	}
+
+	conn, err := sdl.dialUnix(d)
+	if err != nil {
+		return nil, socketError(sdl.raddr, err)
+	}
+
+	return conn, nil
+}
+
+// dialUnix connects to a unix socket, picking the network according
+// to the connection type.
+func (sdl *Sysdlog) dialUnix(d net.Dialer) (net.Conn, error) {
+	switch sdl.connType {
+	case ConnDatagram:
+		return d.Dial("unixgram", sdl.raddr)
+	case ConnStream:
+		return d.Dial("unix", sdl.raddr)
+	}
+
+	conn, err := d.Dial(sdl.network, sdl.raddr)
+	if err != nil && sdl.network == "unixgram" && errors.Is(err, syscall.EPROTOTYPE) {
+		if sdl.coalesce != nil && sdl.keepsNewlines() {
+			return nil, errCoalesceStream
+		}
+		return d.Dial("unix", sdl.raddr)
+	}
+
+	return conn, err
+}
+
+// socketError wraps err from dialing the unix socket at path with
+// ErrSocketMissing or ErrNotSocket if the path doesn't exist or isn't
+// a socket. Other errors are returned as they are.
+func socketError(path string, err error) error {
+	fi, serr := os.Stat(path)
+	switch {
+	case errors.Is(serr, fs.ErrNotExist):
+		return fmt.Errorf("%w: %s", ErrSocketMissing, path)
+	case serr == nil && fi.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("%w: %s is a %s", ErrNotSocket, path, fileKind(fi.Mode()))
+	}
+
+	return err
+}
+
+// fileKind describes the type of file for a mode, for errors.
+func fileKind(m fs.FileMode) string {
+	switch {
+	case m.IsRegular():
+		return "regular file"
+	case m.IsDir():
+		return "directory"
+	case m&fs.ModeSymlink != 0:
+		return "symlink"
+	case m&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case m&fs.ModeDevice != 0:
+		return "device"
+	}
+
+	return "non-socket file"
+}
+
+// isStream reports whether conn is a stream connection, where
+// messages have to be terminated so they can be told apart.
+func isStream(conn net.Conn) bool {
+	switch a := conn.RemoteAddr().(type) {
+	case *net.UnixAddr:
+		return a.Net == "unix"
+	case *net.TCPAddr:
+		return true
+	}
+
+	return false
+}
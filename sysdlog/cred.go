@@ -0,0 +1,26 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+// Credentials are the process credentials sent with each message when
+// the logger is created with WithCredentials.
+type Credentials struct {
+	PID int
+	UID int
+	GID int
+}
+
+// WithCredentials enables SO_PASSCRED on the socket and sends c as
+// SCM_CREDENTIALS ancillary data with every message. journald uses
+// these credentials for the _PID, _UID and _GID trusted fields. The
+// kernel only accepts credentials other than the process's own from
+// privileged processes. This only works with unix sockets on Linux.
+// Elsewhere connecting fails with an error wrapping
+// errors.ErrUnsupported, unless WithIgnoreUnsupported is also given.
+func WithCredentials(c Credentials) Option {
+	return func(sdl *Sysdlog) {
+		sdl.creds = &c
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// setPassCred enables SO_PASSCRED on the socket underlying conn.
+func setPassCred(conn net.Conn) error {
+	if _, ok := conn.(*net.UnixConn); !ok {
+		return errors.ErrUnsupported
+	}
+
+	return controlConn(conn, func(fd int) error {
+		return syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_PASSCRED, 1)
+	})
+}
+
+// writeWithCred writes b to conn with c attached as SCM_CREDENTIALS.
+// The socket is already connected, which rules out WriteMsgUnix for
+// datagrams, so sendmsg is called directly.
+func writeWithCred(conn net.Conn, b []byte, c *Credentials) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	oob := syscall.UnixCredentials(&syscall.Ucred{
+		Pid: int32(c.PID),
+		Uid: uint32(c.UID),
+		Gid: uint32(c.GID),
+	})
+
+	var serr error
+	err = rc.Write(func(fd uintptr) bool {
+		serr = syscall.Sendmsg(int(fd), b, oob, nil, 0)
+		return serr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+
+	return serr
+}
@@ -0,0 +1,22 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+//go:build !linux
+
+package sysdlog
+
+import (
+	"errors"
+	"net"
+)
+
+// setPassCred isn't supported on this platform.
+func setPassCred(conn net.Conn) error {
+	return errors.ErrUnsupported
+}
+
+// writeWithCred isn't supported on this platform.
+func writeWithCred(conn net.Conn, b []byte, c *Credentials) error {
+	return errors.ErrUnsupported
+}
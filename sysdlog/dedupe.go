@@ -0,0 +1,140 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// WithWindowDedupe suppresses repeats of a message seen within window
+// of its first occurrence, even when other messages come in between.
+// Messages are matched by a hash of their severity and text. Once the
+// window has passed the message is forgotten and, if repeats were
+// suppressed, a summary with their number is logged. Windows are
+// checked each time a message is logged, and FlushSync and Close end
+// the windows still open, logging their summaries. At most maxKeys
+// messages are remembered; the least recently seen is forgotten
+// first, and its summary logged.
+func WithWindowDedupe(window time.Duration, maxKeys int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.dedupe = &dedupe{
+			window: window,
+			max:    maxKeys,
+			lru:    list.New(),
+			starts: list.New(),
+			keys:   make(map[uint64]*dedupeEntry),
+		}
+	}
+}
+
+// dedupe is a bounded LRU of recently seen messages. Entries are also
+// kept in the order their windows started, so expired windows can be
+// found without looking at every entry.
+type dedupe struct {
+	window time.Duration
+	max    int
+	lru    *list.List
+	starts *list.List
+	keys   map[uint64]*dedupeEntry
+}
+
+// dedupeEntry tracks one message in the LRU.
+type dedupeEntry struct {
+	hash       uint64
+	s          Severity
+	prefix     string
+	m          string
+	first      time.Time
+	suppressed int
+
+	seen  *list.Element
+	start *list.Element
+}
+
+// dedupeSummary is a summary of suppressed repeats to log.
+type dedupeSummary struct {
+	s      Severity
+	prefix string
+	m      string
+}
+
+// check reports whether the message should be suppressed. It also
+// returns the summaries of messages forgotten along the way, which
+// should be logged first.
+func (d *dedupe) check(s Severity, prefix, m string, now time.Time) (bool, []dedupeSummary) {
+	var sums []dedupeSummary
+	for el := d.starts.Front(); el != nil; el = d.starts.Front() {
+		e := el.Value.(*dedupeEntry)
+		if now.Sub(e.first) < d.window {
+			break
+		}
+		sums = d.forget(e, sums)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	h.Write([]byte(m))
+	key := h.Sum64()
+
+	if e, ok := d.keys[key]; ok {
+		d.lru.MoveToFront(e.seen)
+		e.suppressed++
+		return true, sums
+	}
+
+	e := &dedupeEntry{hash: key, s: s, prefix: prefix, m: m, first: now}
+	e.seen = d.lru.PushFront(e)
+	e.start = d.starts.PushBack(e)
+	d.keys[key] = e
+	for d.max > 0 && d.lru.Len() > d.max {
+		sums = d.forget(d.lru.Back().Value.(*dedupeEntry), sums)
+	}
+
+	return false, sums
+}
+
+// forget removes e, adding its summary to sums if any repeats were
+// suppressed.
+func (d *dedupe) forget(e *dedupeEntry, sums []dedupeSummary) []dedupeSummary {
+	d.lru.Remove(e.seen)
+	d.starts.Remove(e.start)
+	delete(d.keys, e.hash)
+
+	if e.suppressed == 0 {
+		return sums
+	}
+
+	m := fmt.Sprintf("suppressed %d repeats of %q", e.suppressed, e.m)
+	return append(sums, dedupeSummary{s: e.s, prefix: e.prefix, m: m})
+}
+
+// forgetAll removes every entry and returns their summaries.
+func (d *dedupe) forgetAll() []dedupeSummary {
+	var sums []dedupeSummary
+	for el := d.starts.Front(); el != nil; el = d.starts.Front() {
+		sums = d.forget(el.Value.(*dedupeEntry), sums)
+	}
+
+	return sums
+}
+
+// logSummaries sends summaries of suppressed repeats. The caller must
+// hold the lock.
+func (sdl *Sysdlog) logSummaries(sums []dedupeSummary) {
+	for _, sum := range sums {
+		sdl.deliver(sum.s, sdl.format(sum.s, sum.prefix, sum.m))
+	}
+}
+
+// flushDedupe logs the summaries of every window still open. The
+// caller must hold the lock.
+func (sdl *Sysdlog) flushDedupe() {
+	if sdl.dedupe != nil {
+		sdl.logSummaries(sdl.dedupe.forgetAll())
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// WithJSONLevelDetection makes Write read the severity from the
+// "level" field of JSON lines, such as those written by slog's
+// JSONHandler. The slog levels map to LOG_DEBUG, LOG_INFO,
+// LOG_WARNING and LOG_ERR, and the names ParseSeverity knows are
+// accepted too. The whole line is sent as the message. Lines that
+// aren't JSON objects or have no usable level are left to any other
+// level detection, and otherwise use the default severity.
+func WithJSONLevelDetection() Option {
+	return func(sdl *Sysdlog) {
+		sdl.detectors = append(sdl.detectors, detectJSONLevel)
+	}
+}
+
+// detectJSONLevel reads the level field of a JSON object in m.
+func detectJSONLevel(m string) (Severity, string, bool) {
+	t := strings.TrimSpace(m)
+	if !strings.HasPrefix(t, "{") {
+		return "", m, false
+	}
+
+	var v struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(t), &v); err != nil || v.Level == "" {
+		return "", m, false
+	}
+
+	if s, ok := parseLevel(v.Level); ok {
+		return s, m, true
+	}
+
+	return "", m, false
+}
+
+// WithLogfmtLevelDetection makes Write read the severity from the
+// "level" key of logfmt lines like `level=WARN msg="disk full"`, as
+// written by slog's TextHandler and many other loggers. Quoted values
+// are allowed, and levels are read as for WithJSONLevelDetection. The
+// whole line is sent as the message. Lines without a usable level
+// fall through to any other level detection, or use the default
+// severity.
+func WithLogfmtLevelDetection() Option {
+	return func(sdl *Sysdlog) {
+		sdl.detectors = append(sdl.detectors, detectLogfmtLevel)
+	}
+}
+
+// detectLogfmtLevel reads the level key of a logfmt line in m.
+func detectLogfmtLevel(m string) (Severity, string, bool) {
+	v, ok := logfmtValue(m, "level")
+	if !ok {
+		return "", m, false
+	}
+
+	if s, ok := parseLevel(v); ok {
+		return s, m, true
+	}
+
+	return "", m, false
+}
+
+// logfmtValue returns the value of key in the logfmt line l.
+func logfmtValue(l, key string) (string, bool) {
+	for l != "" {
+		l = strings.TrimLeft(l, " \t\r\n")
+
+		end := strings.IndexAny(l, "= \t")
+		if end < 0 {
+			return "", false
+		}
+		k := l[:end]
+		l = l[end:]
+		if l[0] != '=' {
+			continue
+		}
+		l = l[1:]
+
+		var v string
+		if strings.HasPrefix(l, `"`) {
+			q, err := strconv.QuotedPrefix(l)
+			if err != nil {
+				return "", false
+			}
+			v, _ = strconv.Unquote(q)
+			l = l[len(q):]
+		} else {
+			end := strings.IndexAny(l, " \t\r\n")
+			if end < 0 {
+				end = len(l)
+			}
+			v, l = l[:end], l[end:]
+		}
+
+		if k == key {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// parseLevel returns the severity for a slog level or any name that
+// ParseSeverity accepts.
+func parseLevel(l string) (Severity, bool) {
+	if s, ok := slogLevel(l); ok {
+		return s, true
+	}
+
+	s, err := ParseSeverity(l)
+	return s, err == nil
+}
+
+// slogLevels are the numeric values of slog's named levels.
+var slogLevels = map[string]int{
+	"DEBUG": -4,
+	"INFO":  0,
+	"WARN":  4,
+	"ERROR": 8,
+}
+
+// slogLevel returns the severity for a level as slog prints it, like
+// "WARN" or "INFO+2".
+func slogLevel(l string) (Severity, bool) {
+	name, offset := strings.ToUpper(l), 0
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		n, err := strconv.Atoi(name[i:])
+		if err != nil {
+			return "", false
+		}
+		name, offset = name[:i], n
+	}
+
+	base, ok := slogLevels[name]
+	if !ok {
+		return "", false
+	}
+
+	switch v := base + offset; {
+	case v >= 8:
+		return LOG_ERR, true
+	case v >= 4:
+		return LOG_WARNING, true
+	case v >= 0:
+		return LOG_INFO, true
+	}
+
+	return LOG_DEBUG, true
+}
@@ -0,0 +1,52 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "strings"
+
+// emptyPlaceholder replaces empty messages under EmptyPlaceholder.
+const emptyPlaceholder = "(empty message)"
+
+// EmptyMessagePolicy decides what happens to messages that are empty
+// or contain only whitespace, such as log.Println("").
+type EmptyMessagePolicy int
+
+const (
+	// EmptySkip doesn't send empty messages at all. This is the
+	// default.
+	EmptySkip EmptyMessagePolicy = iota
+
+	// EmptyPlaceholder sends "(empty message)" instead.
+	EmptyPlaceholder
+
+	// EmptyKeep sends the message as is, which shows up in the
+	// journal as a line with only the prefix.
+	EmptyKeep
+)
+
+// WithEmptyMessagePolicy sets how empty and whitespace-only messages
+// are handled.
+func WithEmptyMessagePolicy(p EmptyMessagePolicy) Option {
+	return func(sdl *Sysdlog) {
+		sdl.emptyPolicy = p
+	}
+}
+
+// handleEmpty applies the empty message policy to m. It returns the
+// message to send, or false if it should be skipped.
+func (sdl *Sysdlog) handleEmpty(m string) (string, bool) {
+	if strings.TrimSpace(m) != "" {
+		return m, true
+	}
+
+	switch sdl.emptyPolicy {
+	case EmptyPlaceholder:
+		return emptyPlaceholder, true
+	case EmptyKeep:
+		return m, true
+	default:
+		return "", false
+	}
+}
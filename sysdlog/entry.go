@@ -0,0 +1,168 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Entry is a single log message and the severity it should be logged
+// with.
+type Entry struct {
+	Severity Severity
+	Message  string
+}
+
+// Replay sends the given entries to the logger in order. It stops at
+// the first entry that fails and returns the number of entries that
+// were sent successfully along with the error.
+func (sdl *Sysdlog) Replay(entries []Entry) (int, error) {
+	for i, e := range entries {
+		if _, err := sdl.writeRetry(e.Severity, e.Message); err != nil {
+			return i, err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// ReplayFile sends the lines of a spool file, such as one written by
+// a WithFallback writer while the systemd logger was down, to the
+// logger in order. Each line must start with a "<N>" priority, which
+// decides its severity; the lines are sent as they are, since they
+// are already formatted. Blank lines are ignored and lines without a
+// priority are skipped and reported in the error once the rest of the
+// file has been sent. Files compressed with gzip, like those written
+// by WithSpoolDir, are decompressed as they are read. The lines only
+// go to the socket, never to the retry queue, fallback writer or
+// stderr mirror, so ReplayFile stops with an error at the first line
+// the socket doesn't take, and a nil error means every line was sent.
+// It refuses to replay the file the spool or fallback writer is
+// writing to. It returns the number of lines sent.
+func (sdl *Sysdlog) ReplayFile(path string) (int, error) {
+	if sdl == nil {
+		return 0, ErrNilLogger
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if fi, err := f.Stat(); err == nil && sdl.writingTo(fi) {
+		return 0, fmt.Errorf("sysdlog: can't replay %s while the logger is writing to it", path)
+	}
+
+	r := bufio.NewReader(f)
+	if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = bufio.NewReader(gz)
+	}
+
+	n, skipped := 0, 0
+	for {
+		line, rerr := r.ReadBytes('\n')
+		if rerr != nil && !errors.Is(rerr, io.EOF) {
+			return n, rerr
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			p, _, ok := parsePRI(line)
+			if !ok {
+				skipped++
+			} else {
+				if line[len(line)-1] != '\n' {
+					line = append(line, '\n')
+				}
+				if err := sdl.replayLine(severityFromLevel(int(p&severityMask)), line); err != nil {
+					return n, err
+				}
+				n++
+			}
+		}
+
+		if rerr != nil {
+			break
+		}
+	}
+
+	if skipped > 0 {
+		return n, fmt.Errorf("sysdlog: skipped %d malformed lines in %s", skipped, path)
+	}
+
+	return n, nil
+}
+
+// replayLine sends one line from ReplayFile to the socket.
+func (sdl *Sysdlog) replayLine(s Severity, b []byte) error {
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return ErrClosed
+	}
+
+	if !sdl.enabled(s) {
+		return nil
+	}
+
+	return sdl.sendSocket(s, b)
+}
+
+// writingTo reports whether the spool or fallback writer is writing to
+// the file fi.
+func (sdl *Sysdlog) writingTo(fi os.FileInfo) bool {
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	var w *os.File
+	switch {
+	case sdl.spool != nil:
+		w = sdl.spool.f
+	default:
+		w, _ = sdl.fallback.(*os.File)
+	}
+	if w == nil {
+		return false
+	}
+
+	wi, err := w.Stat()
+	return err == nil && os.SameFile(fi, wi)
+}
+
+// WithEntryHook adds a hook that sees every message before it is
+// sent. The hook may change the entry's severity or message. If it
+// returns true, the message is dropped and counted in Stats. Hooks run
+// in the order they were added, and a dropped message isn't passed to
+// later hooks. Hooks are called while the logger is locked and must
+// not log to the same logger.
+func WithEntryHook(hook func(e *Entry) (drop bool)) Option {
+	return func(sdl *Sysdlog) {
+		sdl.hooks = append(sdl.hooks, hook)
+	}
+}
+
+// runHooks passes e through each hook in turn and reports whether one
+// of them dropped it.
+func (sdl *Sysdlog) runHooks(e *Entry) bool {
+	for _, hook := range sdl.hooks {
+		if hook(e) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "regexp"
+
+// WithMessageBlocklist drops every message that matches one of the
+// patterns, such as a noisy line from a library. Dropped messages are
+// counted in Stats. The blocklist wins over WithMessageAllowlist.
+func WithMessageBlocklist(patterns []*regexp.Regexp) Option {
+	return func(sdl *Sysdlog) {
+		sdl.blocklist = patterns
+	}
+}
+
+// WithMessageAllowlist drops every message that doesn't match at
+// least one of the patterns, which helps when debugging one part of a
+// program. Dropped messages are counted in Stats. An empty list allows
+// everything.
+func WithMessageAllowlist(patterns []*regexp.Regexp) Option {
+	return func(sdl *Sysdlog) {
+		sdl.allowlist = patterns
+	}
+}
+
+// allowed reports whether m passes the blocklist and allowlist.
+func (sdl *Sysdlog) allowed(m string) bool {
+	for _, re := range sdl.blocklist {
+		if re.MatchString(m) {
+			return false
+		}
+	}
+
+	if len(sdl.allowlist) == 0 {
+		return true
+	}
+
+	for _, re := range sdl.allowlist {
+		if re.MatchString(m) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errNotConnected is returned by FlushSync when the logger has no
+// connection to the systemd logger.
+var errNotConnected = errors.New("sysdlog: not connected")
+
+// FlushSync pushes out anything the logger is holding and reports
+// whether the connection is usable. Messages in the retry queue are
+// sent if the socket accepts them, and the fallback writer is flushed
+// or synced if it has a Flush() error or Sync() error method.
+//
+// Apart from what WithCoalesceWindow holds, messages are never
+// buffered on the way to the socket: a write that returned without
+// error has been handed to the kernel. That is the strongest
+// guarantee available. Neither datagram nor stream syslog sockets
+// acknowledge messages, so there's no way to know journald has read
+// or stored them.
+func (sdl *Sysdlog) FlushSync() error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return ErrClosed
+	}
+
+	return sdl.flush()
+}
+
+// flush does the work of FlushSync. The caller must hold the lock.
+func (sdl *Sysdlog) flush() error {
+	start := sdl.now()
+	sdl.flushDedupe()
+	sdl.flushHeld()
+
+	var errs []error
+
+	if sdl.retryQueue != nil && !sdl.drainRetryQueue() {
+		errs = append(errs, fmt.Errorf("sysdlog: %d messages still queued for retry", len(sdl.retryQueue.entries)))
+	}
+
+	switch f := sdl.fallback.(type) {
+	case interface{ Flush() error }:
+		errs = append(errs, f.Flush())
+	case interface{ Sync() error }:
+		errs = append(errs, f.Sync())
+	}
+
+	if sdl.conn == nil && sdl.textSink == nil {
+		errs = append(errs, errNotConnected)
+	}
+
+	err := errors.Join(errs...)
+	if err == nil {
+		sdl.recordFlush(start)
+	}
+
+	return err
+}
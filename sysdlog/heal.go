@@ -0,0 +1,87 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"time"
+)
+
+// errDegraded is passed to the fallback stage for messages logged
+// while the logger is degraded.
+var errDegraded = errors.New("sysdlog: logger is degraded")
+
+// WithSelfHealing runs a Probe every interval in the background.
+// After threshold probes in a row have failed, the logger is
+// degraded: messages go straight to the retry queue or fallback
+// writer, or are dropped, without touching the socket, so a dead
+// journal doesn't slow down every call with reconnect attempts. The
+// probes keep running and the first one that succeeds ends the
+// degraded mode. Each probe writes a LOG_DEBUG message to the journal.
+// Stats reports whether the logger is degraded. Close stops the
+// probes.
+func WithSelfHealing(interval time.Duration, threshold int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.healInterval = interval
+		sdl.healThreshold = threshold
+	}
+}
+
+// startHealing starts the probe goroutine if self healing is on.
+func (sdl *Sysdlog) startHealing() {
+	if sdl.healInterval <= 0 {
+		return
+	}
+
+	sdl.stopHeal = make(chan struct{})
+	go sdl.heal(sdl.stopHeal)
+}
+
+// heal probes the logger every interval until stop is closed.
+func (sdl *Sysdlog) heal(stop chan struct{}) {
+	t := time.NewTicker(sdl.healInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			sdl.healthCheck()
+		}
+	}
+}
+
+// healthCheck runs one probe and moves the logger into or out of the
+// degraded mode.
+func (sdl *Sysdlog) healthCheck() {
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return
+	}
+
+	if err := sdl.probe(); err != nil {
+		sdl.counters.setLastError(err)
+		sdl.healFailures++
+		if sdl.healFailures >= sdl.healThreshold {
+			sdl.degraded.Store(true)
+		}
+		return
+	}
+
+	sdl.healFailures = 0
+	sdl.degraded.Store(false)
+}
+
+// stopHealing stops the probe goroutine, if there is one. The caller
+// must hold the lock.
+func (sdl *Sysdlog) stopHealing() {
+	if sdl.stopHeal != nil {
+		close(sdl.stopHeal)
+		sdl.stopHeal = nil
+	}
+}
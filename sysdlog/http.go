@@ -0,0 +1,42 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// HTTPErrorLog returns a log.Logger suitable for http.Server's
+// ErrorLog that logs to sdl with severity LOG_ERR. For a different
+// severity, use log.New(sdl.AtLevel(s), "", 0) instead.
+func (sdl *Sysdlog) HTTPErrorLog() *log.Logger {
+	return log.New(sdl.AtLevel(LOG_ERR), "", 0)
+}
+
+// RecoveryMiddleware wraps next so that a panic while serving a
+// request is logged with severity LOG_CRIT, along with the stack
+// trace, and answered with a 500 Internal Server Error instead of
+// dropping the connection. http.ErrAbortHandler is passed through
+// untouched, since it is the documented way to abort a response.
+func (sdl *Sysdlog) RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			sdl.Critf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, v, debug.Stack())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
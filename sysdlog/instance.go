@@ -0,0 +1,43 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+// WithInstanceID starts every message with a "logger_id=<id> " token,
+// so the messages of several loggers in one process can be told
+// apart. An empty id is replaced by 8 random hex characters when the
+// logger is created. The id stays the same for the logger's lifetime
+// and is shared by the Batches and LeveledWriters made from it.
+func WithInstanceID(id string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.instanceID = id
+		sdl.instanceToken = true
+	}
+}
+
+// InstanceID returns the id set by WithInstanceID, or an empty string
+// if the logger doesn't have one.
+func (sdl *Sysdlog) InstanceID() string {
+	if sdl == nil {
+		return ""
+	}
+
+	return sdl.instanceID
+}
+
+// setInstanceID generates the instance id if one was asked for
+// without a value.
+func (sdl *Sysdlog) setInstanceID() error {
+	if !sdl.instanceToken || sdl.instanceID != "" {
+		return nil
+	}
+
+	id, err := newID(4)
+	if err != nil {
+		return err
+	}
+
+	sdl.instanceID = id
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"fmt"
+	"io"
+)
+
+// LeveledWriter logs every message at one fixed severity. It shares
+// the connection and configuration of the Sysdlog it came from.
+type LeveledWriter struct {
+	sdl *Sysdlog
+	s   Severity
+}
+
+// AtLevel returns a LeveledWriter that logs to sdl at severity s.
+func (sdl *Sysdlog) AtLevel(s Severity) *LeveledWriter {
+	return &LeveledWriter{sdl: sdl, s: s}
+}
+
+// Severity returns the severity the writer logs at.
+func (lw *LeveledWriter) Severity() Severity {
+	return lw.s
+}
+
+// Log logs a message.
+func (lw *LeveledWriter) Log(m string) error {
+	_, err := lw.sdl.writeRetry(lw.s, m)
+	return err
+}
+
+// Logf logs a formatted message.
+func (lw *LeveledWriter) Logf(format string, v ...interface{}) error {
+	_, err := lw.sdl.writeRetry(lw.s, fmt.Sprintf(format, v...))
+	return err
+}
+
+// Write logs b as a single message. Like Sysdlog.Write, it returns
+// io.ErrShortWrite when the message was truncated.
+func (lw *LeveledWriter) Write(b []byte) (int, error) {
+	n, err := lw.sdl.writeRetry(lw.s, string(b))
+	if err != nil {
+		return 0, err
+	}
+
+	if n < len(b) {
+		return n, io.ErrShortWrite
+	}
+
+	return n, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxLineBuffer is the longest line a lineBuffer holds. Longer lines
+// are handed back in pieces, so output that never ends its line can't
+// grow the buffer without limit.
+const maxLineBuffer = 64 << 10
+
+// lineBuffer collects written bytes and hands back complete lines.
+type lineBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// write adds b to the buffer and calls emit with each line that is
+// now complete, without its newline. It returns the first error from
+// emit. The buffer is locked while emit runs, so lines from
+// concurrent writes don't interleave.
+func (lb *lineBuffer) write(b []byte, emit func(line string) error) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.buf = append(lb.buf, b...)
+
+	var first error
+	for {
+		line, ok := lb.next()
+		if !ok {
+			break
+		}
+
+		if err := emit(line); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	// Don't hang on to a large backing array once it has been
+	// consumed.
+	if len(lb.buf) == 0 {
+		lb.buf = nil
+	}
+
+	return first
+}
+
+// next removes the next complete line from the buffer, without its
+// newline, cutting lines longer than maxLineBuffer into pieces. It
+// reports false if there is no such line yet.
+func (lb *lineBuffer) next() (string, bool) {
+	i := bytes.IndexByte(lb.buf, '\n')
+	switch {
+	case i >= 0 && i <= maxLineBuffer:
+		line := string(lb.buf[:i])
+		lb.buf = lb.buf[i+1:]
+		return line, true
+	case len(lb.buf) > maxLineBuffer:
+		n := cutLine(lb.buf)
+		line := string(lb.buf[:n])
+		lb.buf = lb.buf[n:]
+		return line, true
+	}
+
+	return "", false
+}
+
+// cutLine returns where to cut a line longer than maxLineBuffer: at
+// the limit, moved back to the start of a UTF-8 character if that
+// leaves anything.
+func cutLine(b []byte) int {
+	n := maxLineBuffer
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	if n == 0 {
+		return maxLineBuffer
+	}
+
+	return n
+}
+
+// flush calls emit with any partial line left in the buffer.
+func (lb *lineBuffer) flush(emit func(line string) error) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if len(lb.buf) == 0 {
+		return nil
+	}
+
+	line := string(lb.buf)
+	lb.buf = nil
+	return emit(line)
+}
+
+// LineWriter is an io.Writer that logs one message per line, however
+// the lines are split across calls to Write. This suits piped output,
+// which often arrives in pieces. Each line is logged as if passed to
+// Sysdlog.Write, so level detection applies. Lines longer than 64 KiB,
+// such as a progress bar that never ends its line, are logged in
+// pieces of at most that size.
+type LineWriter struct {
+	sdl   *Sysdlog
+	lines lineBuffer
+}
+
+// NewLineWriter returns a LineWriter that logs to sdl.
+func NewLineWriter(sdl *Sysdlog) *LineWriter {
+	return &LineWriter{sdl: sdl}
+}
+
+// Write logs every complete line in b. A trailing partial line is
+// kept until a later Write completes it or Flush is called. It always
+// reports all of b as written, along with the first error from
+// logging a line.
+func (lw *LineWriter) Write(b []byte) (int, error) {
+	return len(b), lw.lines.write(b, lw.log)
+}
+
+// Flush logs any partial line still buffered.
+func (lw *LineWriter) Flush() error {
+	return lw.lines.flush(lw.log)
+}
+
+// Close flushes the writer. It doesn't close the underlying logger.
+func (lw *LineWriter) Close() error {
+	return lw.Flush()
+}
+
+// log logs one line.
+func (lw *LineWriter) log(line string) error {
+	if lw.sdl == nil {
+		return ErrNilLogger
+	}
+
+	s, m := lw.sdl.detectLevel(line)
+	_, err := lw.sdl.writeRetry(s, m)
+	return err
+}
+
+// PrefixRule maps lines that start with a match for Pattern to
+// Severity.
+type PrefixRule struct {
+	Pattern  *regexp.Regexp
+	Severity Severity
+}
+
+// SeverityWriter is an io.Writer that splits what is written into
+// lines and logs each line with a severity chosen by a list of
+// PrefixRules. It is meant for the output of third party libraries
+// that log through the standard log package with their own level
+// prefixes. Long lines are split into pieces as by LineWriter.
+type SeverityWriter struct {
+	sdl   *Sysdlog
+	rules []PrefixRule
+	lines lineBuffer
+}
+
+// NewSeverityWriter returns a SeverityWriter that logs to sdl. Each
+// line is checked against the rules in order, and the first rule
+// whose pattern matches at the start of the line sets its severity.
+// Lines that match no rule use sdl's default severity.
+func NewSeverityWriter(sdl *Sysdlog, rules []PrefixRule) *SeverityWriter {
+	return &SeverityWriter{sdl: sdl, rules: rules}
+}
+
+// Write logs every complete line in b. A trailing partial line is
+// kept until a later Write completes it or Flush is called. It always
+// reports all of b as written, along with the first error from
+// logging a line.
+func (sw *SeverityWriter) Write(b []byte) (int, error) {
+	return len(b), sw.lines.write(b, sw.log)
+}
+
+// Flush logs any partial line still buffered.
+func (sw *SeverityWriter) Flush() error {
+	return sw.lines.flush(sw.log)
+}
+
+// Close flushes the writer. It doesn't close the underlying logger.
+func (sw *SeverityWriter) Close() error {
+	return sw.Flush()
+}
+
+// log classifies and logs one line.
+func (sw *SeverityWriter) log(line string) error {
+	if sw.sdl == nil {
+		return ErrNilLogger
+	}
+
+	s := sw.sdl.severity
+	for _, r := range sw.rules {
+		if loc := r.Pattern.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			s = r.Severity
+			break
+		}
+	}
+
+	_, err := sw.sdl.writeRetry(s, line)
+	return err
+}
@@ -0,0 +1,316 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option configures a Sysdlog. Options are passed to New and applied
+// before the first connection is made.
+type Option func(*Sysdlog)
+
+// WithSeverityRemap transforms the severity of every message before
+// it is written. A message logged with a severity that is a key in m
+// is sent with the corresponding value instead, so LOG_NOTICE could
+// be folded into LOG_INFO without touching any call sites.
+func WithSeverityRemap(m map[Severity]Severity) Option {
+	return func(sdl *Sysdlog) {
+		sdl.remap = copyRemap(m)
+	}
+}
+
+// SetSeverityRemap replaces the severity remapping at runtime. It is
+// safe to call while other goroutines are logging. A nil map removes
+// any remapping.
+func (sdl *Sysdlog) SetSeverityRemap(m map[Severity]Severity) {
+	if sdl == nil {
+		return
+	}
+
+	r := copyRemap(m)
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	sdl.remap = r
+}
+
+// copyRemap makes a private copy of m so callers can't change the
+// mapping out from under the lock.
+func copyRemap(m map[Severity]Severity) map[Severity]Severity {
+	if len(m) == 0 {
+		return nil
+	}
+
+	r := make(map[Severity]Severity, len(m))
+	for k, v := range m {
+		r[k] = v
+	}
+
+	return r
+}
+
+// WithLevelDetection makes Write look for a leading level token such
+// as "[WARN]" or "ERROR:" in each message. When one is found, the
+// message is logged with the matching severity and the token is
+// removed. Messages without a token use the default severity. This is
+// useful when the logger is the output of a log.Logger whose lines
+// already carry a level. It can be combined with
+// WithJSONLevelDetection and WithLogfmtLevelDetection, in which case
+// each is tried in the order the options were given and the first to
+// find a level wins.
+func WithLevelDetection() Option {
+	return func(sdl *Sysdlog) {
+		sdl.detectors = append(sdl.detectors, detectLevelToken)
+	}
+}
+
+// WithSendBufferSize sets SO_SNDBUF on the socket to the given number
+// of bytes each time it is dialed. A larger buffer helps avoid dropped
+// datagrams under bursty load. Connecting fails with an error wrapping
+// errors.ErrUnsupported on platforms that can't set it, unless
+// WithIgnoreUnsupported is also given.
+func WithSendBufferSize(bytes int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.sndbuf = bytes
+	}
+}
+
+// WithTCPKeepAlive enables TCP keepalive probes with the given period
+// on connections made with Dial over TCP, so a collector that went
+// away without closing the connection is noticed and the logger
+// reconnects. A negative period turns keepalive off. Without this
+// option Go's default keepalive applies. It has no effect on other
+// networks.
+func WithTCPKeepAlive(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.keepAlive = d
+	}
+}
+
+// WithIgnoreUnsupported silently skips socket options that the
+// platform doesn't support instead of failing to connect.
+func WithIgnoreUnsupported() Option {
+	return func(sdl *Sysdlog) {
+		sdl.ignoreUnsupported = true
+	}
+}
+
+// WithRetryBudget bounds the total time a single message may spend
+// being written, including reconnecting and writing again after a
+// failure. Once the budget is spent the last error is returned. A
+// zero budget, the default, means there is no limit.
+func WithRetryBudget(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.retryBudget = d
+	}
+}
+
+// WithMinSeverity drops any message that is less severe than s. By
+// default every message is sent.
+func WithMinSeverity(s Severity) Option {
+	return func(sdl *Sysdlog) {
+		sdl.minSeverity = s
+	}
+}
+
+// SetMinSeverity changes the minimum severity at runtime. It is safe
+// to call while other goroutines are logging.
+func (sdl *Sysdlog) SetMinSeverity(s Severity) {
+	if sdl == nil {
+		return
+	}
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	sdl.minSeverity = s
+}
+
+// WithSeverityFromPriorityByte makes WriteRaw read the severity from
+// a leading "<PRI>" in the data for filtering. The data itself is
+// still sent unchanged. Data with a missing or malformed PRI uses the
+// default severity.
+func WithSeverityFromPriorityByte() Option {
+	return func(sdl *Sysdlog) {
+		sdl.rawPRI = true
+	}
+}
+
+// WithMessagePrefixFunc computes the prefix for each message by
+// calling f when the message is written. It takes precedence over the
+// prefix given to New. f is called without holding the logger's lock,
+// so it may be slow or log on its own, but it must be safe to call
+// from multiple goroutines.
+func WithMessagePrefixFunc(f func() string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.prefixFunc = f
+	}
+}
+
+// WithFallback gives the logger somewhere to write messages when the
+// systemd logger can't be reached, such as os.Stderr or a file. The
+// fallback receives the same formatted line that would have been
+// sent to the socket. If the fallback fails too, the message is
+// dropped and counted in Stats.
+func WithFallback(w io.Writer) Option {
+	return func(sdl *Sysdlog) {
+		sdl.fallback = w
+	}
+}
+
+// WithDropOnClosed makes messages logged after Close be silently
+// dropped and counted in Stats instead of failing with ErrClosed.
+func WithDropOnClosed() Option {
+	return func(sdl *Sysdlog) {
+		sdl.dropOnClosed = true
+	}
+}
+
+// WithLineSeparatorReplacement replaces characters in each message
+// before it is sent, for example mapping '\t' to four spaces or '\r'
+// to nothing. The trailing newline that ends every message is never
+// replaced. By default nothing is replaced.
+func WithLineSeparatorReplacement(m map[rune]string) Option {
+	return func(sdl *Sysdlog) {
+		if len(m) == 0 {
+			sdl.replacer = nil
+			return
+		}
+
+		pairs := make([]string, 0, len(m)*2)
+		for r, s := range m {
+			pairs = append(pairs, string(r), s)
+		}
+		sdl.replacer = strings.NewReplacer(pairs...)
+	}
+}
+
+// WithMessageTransform rewrites every message with f just before it
+// is sent, which is a central place to redact secrets. f receives the
+// message without its trailing newline. Character replacements from
+// WithLineSeparatorReplacement run after f, so f can't reintroduce
+// characters they remove. f is called while the logger is locked and
+// must not log to the same logger.
+func WithMessageTransform(f func(s Severity, m string) string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.transform = f
+	}
+}
+
+// WithSeverityThresholdFromEnv sets the minimum severity from the
+// environment variable named varName, such as LOG_LEVEL=warning. The
+// value is parsed with ParseSeverity. If the variable is unset or
+// invalid, the minimum severity is left as it was, so an earlier
+// WithMinSeverity acts as the default.
+func WithSeverityThresholdFromEnv(varName string) Option {
+	return func(sdl *Sysdlog) {
+		if s, err := ParseSeverity(os.Getenv(varName)); err == nil {
+			sdl.minSeverity = s
+		}
+	}
+}
+
+// WithSequenceNumbers starts every message with a "seq=<n> " token,
+// where n counts up by one for each message the logger sends. A gap
+// in the numbers in the journal shows that messages were lost on the
+// way.
+func WithSequenceNumbers(on bool) Option {
+	return func(sdl *Sysdlog) {
+		sdl.sequence = on
+	}
+}
+
+// WithSeverityCeiling caps the severity of every message at s, so
+// with a ceiling of LOG_ERR an Emerg message is sent as an error. It
+// applies after WithSeverityRemap and after entry hooks, so it always
+// has the last word. This keeps staging systems from triggering the
+// actions tied to LOG_EMERG and LOG_ALERT.
+func WithSeverityCeiling(s Severity) Option {
+	return func(sdl *Sysdlog) {
+		sdl.ceiling = s
+	}
+}
+
+// WithWriteHook calls f with the exact bytes of each message just
+// before they are written to the socket, including retries, which
+// helps when debugging framing. f is called with the lock held, so it
+// must not log to the same logger, and it must not modify or keep b.
+func WithWriteHook(f func(b []byte)) Option {
+	return func(sdl *Sysdlog) {
+		sdl.writeHook = f
+	}
+}
+
+// WithEmbeddedPriorityToken appends a " PRIORITY=<n>" field to the end
+// of every message, where n is the numeric severity, for bridges that
+// read journald's PRIORITY field from the text instead of the "<N>"
+// prefix. The prefix is still sent.
+func WithEmbeddedPriorityToken(on bool) Option {
+	return func(sdl *Sysdlog) {
+		sdl.embedPriority = on
+	}
+}
+
+// WithStderrMirrorAbove also writes every message at least as severe
+// as s to os.Stderr, before it is sent to the systemd logger, so a
+// crash message is visible even if the process dies before the
+// journal receives it. The write is synchronous. It has no effect
+// when WithAutoSink has already chosen stderr.
+func WithStderrMirrorAbove(s Severity) Option {
+	return func(sdl *Sysdlog) {
+		sdl.mirrorAbove = s
+		sdl.mirror = os.Stderr
+	}
+}
+
+// WithSeverityInMessage also starts every message with a level token
+// like "[WARN]", so the severity survives bridges that strip or mangle
+// the "<N>" prefix. The prefix is still sent, and the token comes
+// after the logger's prefix and before any other tokens. It isn't
+// added on the text sink chosen by WithAutoSink, whose lines already
+// name the severity.
+func WithSeverityInMessage(on bool) Option {
+	return func(sdl *Sysdlog) {
+		sdl.levelToken = on
+	}
+}
+
+// WithJournalNamespace connects to the syslog socket of the journal
+// namespace name, /run/systemd/journal.<name>/dev-log, instead of
+// /dev/log, so the logs are kept apart from the main journal. An
+// empty name means the main journal. The namespace's journald
+// instance must be running; if it isn't, New fails with
+// ErrSocketMissing. It conflicts with connecting Dial to any other
+// address.
+func WithJournalNamespace(name string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.namespace = name
+	}
+}
+
+// namespaceSocket returns the path of the syslog socket of the
+// journal namespace.
+func (sdl *Sysdlog) namespaceSocket() string {
+	return "/run/systemd/journal." + sdl.namespace + "/dev-log"
+}
+
+// WithEnvelopeHeader puts the bytes returned by header in front of
+// every message written to the socket, for proxies that expect a
+// length prefix or magic bytes before each record. header is called
+// with the length of the message as written, including its newline,
+// for every write, so a message that is sent again gets a fresh
+// header. Over UDP the header counts toward WithUDPMaxPacketSize. The
+// header is counted in Stats' BytesWritten and is seen by
+// WithWriteHook. It isn't added to fallback or text sink output.
+func WithEnvelopeHeader(header func(payloadLen int) []byte) Option {
+	return func(sdl *Sysdlog) {
+		sdl.envelopeHeader = header
+	}
+}
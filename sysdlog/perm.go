@@ -0,0 +1,61 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// ErrSocketPermissions is returned, or reported as a warning, when
+// the unix socket's permissions are looser than allowed by
+// WithSocketPermissionsCheck.
+var ErrSocketPermissions = errors.New("sysdlog: socket permissions are too loose")
+
+// WithSocketPermissionsCheck checks the permissions of the unix socket
+// each time it is connected. Any permission bit set on the socket
+// that isn't in allowed is a problem; for example, an allowed of 0660
+// rejects a world-writable socket. When strict is true the connection
+// fails with ErrSocketPermissions. Otherwise a warning is written to
+// os.Stderr and recorded as the last error in Stats, and the logger
+// carries on. Note that the systemd logger's own socket is normally
+// 0666. It has no effect on network connections made with Dial.
+func WithSocketPermissionsCheck(allowed fs.FileMode, strict bool) Option {
+	return func(sdl *Sysdlog) {
+		sdl.permCheck = true
+		sdl.permAllowed = allowed.Perm()
+		sdl.permStrict = strict
+	}
+}
+
+// checkSocketPerm compares the socket's permissions with the allowed
+// ones. It only returns an error in strict mode.
+func (sdl *Sysdlog) checkSocketPerm() error {
+	if !sdl.permCheck || !sdl.isUnixNetwork() {
+		return nil
+	}
+
+	// A missing socket is left for dial to report.
+	fi, err := os.Stat(sdl.raddr)
+	if err != nil {
+		return nil
+	}
+
+	extra := fi.Mode().Perm() &^ sdl.permAllowed
+	if extra == 0 {
+		return nil
+	}
+
+	err = fmt.Errorf("%w: %s has mode %04o, allowed is %04o", ErrSocketPermissions, sdl.raddr, fi.Mode().Perm(), sdl.permAllowed)
+	if sdl.permStrict {
+		return err
+	}
+
+	sdl.counters.setLastError(err)
+	fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	return nil
+}
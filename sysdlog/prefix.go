@@ -0,0 +1,110 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPrefixStripped is returned for prefixes that systemd would strip
+// from the message, when the logger uses PrefixReject.
+var ErrPrefixStripped = errors.New(`sysdlog: prefix has the form "prefix: " which systemd strips`)
+
+// ErrPrefixTooLong is returned for prefixes longer than the limit set
+// with WithMaxPrefixLen.
+var ErrPrefixTooLong = errors.New("sysdlog: prefix is too long")
+
+// PrefixPolicy decides what happens to a prefix that systemd would
+// strip, like "prefix: " or "[prefix]: ".
+type PrefixPolicy int
+
+const (
+	// PrefixKeep uses the prefix as given. This is the default.
+	PrefixKeep PrefixPolicy = iota
+
+	// PrefixReject fails with ErrPrefixStripped.
+	PrefixReject
+
+	// PrefixRewrite changes the prefix to the recommended
+	// "[prefix] " form.
+	PrefixRewrite
+)
+
+// WithPrefixPolicy sets how prefixes that systemd would strip are
+// handled, both in New and SetPrefix.
+func WithPrefixPolicy(p PrefixPolicy) Option {
+	return func(sdl *Sysdlog) {
+		sdl.prefixPolicy = p
+	}
+}
+
+// WithMaxPrefixLen limits the prefix to n bytes. Longer prefixes make
+// New and SetPrefix fail with ErrPrefixTooLong. Zero, the default,
+// means no limit.
+func WithMaxPrefixLen(n int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.maxPrefixLen = n
+	}
+}
+
+// SetPrefix changes the prefix at runtime. It is checked the same way
+// as the prefix given to New, and is left unchanged if it is rejected.
+// It is safe to call while other goroutines are logging.
+func (sdl *Sysdlog) SetPrefix(prefix string) error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	p, err := sdl.checkPrefix(prefix)
+	if err != nil {
+		return err
+	}
+
+	sdl.prefix = p
+	return nil
+}
+
+// checkPrefix applies the prefix policy and length limit to prefix
+// and returns the prefix to use.
+func (sdl *Sysdlog) checkPrefix(prefix string) (string, error) {
+	if core, ok := strippedPrefix(prefix); ok {
+		switch sdl.prefixPolicy {
+		case PrefixReject:
+			return "", fmt.Errorf("%w: %q", ErrPrefixStripped, prefix)
+		case PrefixRewrite:
+			prefix = "[" + core + "] "
+		}
+	}
+
+	if sdl.maxPrefixLen > 0 && len(prefix) > sdl.maxPrefixLen {
+		return "", fmt.Errorf("%w: %d bytes, limit %d", ErrPrefixTooLong, len(prefix), sdl.maxPrefixLen)
+	}
+
+	return prefix, nil
+}
+
+// strippedPrefix reports whether systemd would treat prefix as a
+// syslog identifier and strip it, and returns the name inside it.
+func strippedPrefix(prefix string) (string, bool) {
+	core, ok := strings.CutSuffix(prefix, ": ")
+	if !ok {
+		return "", false
+	}
+
+	if strings.HasPrefix(core, "[") && strings.HasSuffix(core, "]") {
+		core = core[1 : len(core)-1]
+	}
+
+	if core == "" || strings.ContainsAny(core, " \t[]") {
+		return "", false
+	}
+
+	return core, true
+}
@@ -0,0 +1,42 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "fmt"
+
+// Probe sends a LOG_DEBUG test message with a unique marker straight
+// to the systemd logger, reconnecting if needed, and returns any
+// error. It skips filters, hooks and the fallback, so a nil error
+// means the socket itself accepted the message. Like any datagram, it
+// can't confirm journald stored it.
+func (sdl *Sysdlog) Probe() error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return ErrClosed
+	}
+
+	return sdl.probe()
+}
+
+// probe does the work of Probe. The caller must hold the lock.
+func (sdl *Sysdlog) probe() error {
+	id, err := newID(8)
+	if err != nil {
+		return err
+	}
+
+	m := fmt.Sprintf("%s %sprobe %s\n", sdl.renderSeverity(LOG_DEBUG), sdl.renderTag(), id)
+	if err := sdl.send([]byte(m)); err != nil {
+		return fmt.Errorf("sysdlog: probe failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "time"
+
+// WithGlobalRateLimit caps the logger at ratePerSec messages per
+// second across all severities, allowing bursts of up to burst
+// messages. Messages over the limit are dropped and counted in Stats
+// as RateLimited; no severity is favored, so a flood of debug
+// messages can crowd out errors. The limit is measured with the
+// logger's clock.
+func WithGlobalRateLimit(ratePerSec, burst int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.limiter = &tokenBucket{
+			rate:   float64(ratePerSec),
+			burst:  float64(burst),
+			tokens: float64(burst),
+		}
+	}
+}
+
+// tokenBucket is a simple token bucket rate limiter. It is protected
+// by the logger's lock.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// allow reports whether a message may be sent at time now, taking a
+// token if so.
+func (tb *tokenBucket) allow(now time.Time) bool {
+	if !tb.last.IsZero() {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+	tb.last = now
+
+	if tb.tokens < 1 {
+		return false
+	}
+
+	tb.tokens--
+	return true
+}
@@ -0,0 +1,71 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+// WriteRaw sends b to the logger exactly as given, without adding a
+// severity, prefix or trailing newline. It is meant for relaying lines
+// that are already formatted. The default severity is used for
+// filtering unless WithSeverityFromPriorityByte is given, in which
+// case a leading "<PRI>" in b decides it.
+func (sdl *Sysdlog) WriteRaw(b []byte) (int, error) {
+	if sdl == nil {
+		return 0, ErrNilLogger
+	}
+
+	s := sdl.severity
+	if sdl.rawPRI {
+		if p, _, ok := parsePRI(b); ok {
+			s = severityFromLevel(int(p & severityMask))
+		}
+	}
+
+	return sdl.writeRaw(s, b)
+}
+
+// writeRaw sends b unchanged, filtering and counting it as severity s.
+func (sdl *Sysdlog) writeRaw(s Severity, b []byte) (int, error) {
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return sdl.afterClose(len(b))
+	}
+
+	if !sdl.enabled(s) {
+		return len(b), nil
+	}
+
+	if err := sdl.deliver(s, b); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// parsePRI parses a leading "<PRI>" from b, where PRI is a decimal
+// priority between 0 and 191. It returns the priority and the rest of
+// b. Anything else is reported as not ok.
+func parsePRI(b []byte) (Priority, []byte, bool) {
+	if len(b) < 3 || b[0] != '<' {
+		return 0, b, false
+	}
+
+	p := 0
+	for i := 1; i < len(b) && i <= 4; i++ {
+		switch c := b[i]; {
+		case c == '>' && i > 1:
+			if p > maxPRI {
+				return 0, b, false
+			}
+			return Priority(p), b[i+1:], true
+		case c >= '0' && c <= '9':
+			p = p*10 + int(c-'0')
+		default:
+			return 0, b, false
+		}
+	}
+
+	return 0, b, false
+}
@@ -0,0 +1,195 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithReconnectDelay makes the logger wait d before reconnecting
+// after a failed write. There is no delay by default. The wait
+// happens while the logger is locked, so other messages wait too,
+// and it is cut short if it would exceed the retry budget.
+func WithReconnectDelay(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.reconnectDelay = d
+	}
+}
+
+// WithReconnectJitter randomly spreads the reconnect delay by up to
+// fraction in either direction, so a delay of one second with a
+// fraction of 0.2 waits between 0.8 and 1.2 seconds. This keeps many
+// processes from reconnecting in lockstep after journald restarts.
+// It has no effect without WithReconnectDelay.
+func WithReconnectJitter(fraction float64) Option {
+	return func(sdl *Sysdlog) {
+		sdl.reconnectJitter = fraction
+	}
+}
+
+// WithRand replaces the random source used for reconnect jitter. rand
+// must return numbers in [0, 1), as math/rand's Float64 does, which is
+// the default. Like WithClock, this is mostly useful for making tests
+// deterministic.
+func WithRand(rand func() float64) Option {
+	return func(sdl *Sysdlog) {
+		sdl.rand = rand
+	}
+}
+
+// WithConnectRetryOnNew makes New and Dial try to connect up to
+// attempts times, waiting delay between tries, before giving up. If
+// every attempt fails and a fallback writer is configured, the logger
+// is returned anyway: messages go to the fallback and each one tries
+// to reconnect first, so the logger recovers once the socket appears.
+func WithConnectRetryOnNew(attempts int, delay time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.newAttempts = attempts
+		sdl.newDelay = delay
+	}
+}
+
+// WithNoReconnect makes a failed write return its error right away
+// instead of reconnecting and trying again, for callers that manage
+// their own circuit breaker. The error still goes through the retry
+// queue or fallback writer if there is one. Since it never
+// reconnects, a logger whose connection broke keeps failing and has
+// to be replaced.
+func WithNoReconnect() Option {
+	return func(sdl *Sysdlog) {
+		sdl.noReconnect = true
+	}
+}
+
+// WithResendOnReconnect keeps a copy of the last message written to
+// the socket and sends it again after each reconnect, before the
+// message that found the connection broken. A datagram written just
+// before journald restarts can be accepted by the old socket and then
+// lost, and this recovers it. The cost is that the message is
+// delivered twice whenever it wasn't lost.
+func WithResendOnReconnect() Option {
+	return func(sdl *Sysdlog) {
+		sdl.resend = true
+	}
+}
+
+// keepLast remembers b as the last message written, for
+// WithResendOnReconnect. The caller must hold the lock.
+func (sdl *Sysdlog) keepLast(b []byte) {
+	if sdl.resend {
+		sdl.lastSent = append(sdl.lastSent[:0], b...)
+	}
+}
+
+// resendLast writes the remembered message again on a new connection.
+// It is only sent once. The caller must hold the lock.
+func (sdl *Sysdlog) resendLast(deadline time.Time) error {
+	if len(sdl.lastSent) == 0 {
+		return nil
+	}
+
+	if err := sdl.write(sdl.lastSent, deadline); err != nil {
+		return err
+	}
+	sdl.lastSent = sdl.lastSent[:0]
+
+	return nil
+}
+
+// WithReconnectCallback calls f after each attempt to reconnect,
+// with the attempt number and the error, which is nil when the
+// attempt worked. Attempts are numbered from 1 and start over after a
+// successful reconnect. f is called once the logger's lock has been
+// released, so it may log to the same logger, but attempts made by
+// different goroutines may be reported concurrently.
+func WithReconnectCallback(f func(attempt int, err error)) Option {
+	return func(sdl *Sysdlog) {
+		sdl.onReconnect = f
+	}
+}
+
+// reconnectEvent is a reconnect attempt waiting to be reported.
+type reconnectEvent struct {
+	attempt int
+	err     error
+}
+
+// noteReconnect records a reconnect attempt for the callback. The
+// caller must hold the lock.
+func (sdl *Sysdlog) noteReconnect(err error) {
+	if sdl.onReconnect == nil {
+		return
+	}
+
+	sdl.reconnectAttempt++
+	sdl.reconnectEvents = append(sdl.reconnectEvents, reconnectEvent{attempt: sdl.reconnectAttempt, err: err})
+	if err == nil {
+		sdl.reconnectAttempt = 0
+	}
+}
+
+// unlock releases the logger's lock and then reports any reconnect
+// attempts made while it was held.
+func (sdl *Sysdlog) unlock() {
+	events := sdl.reconnectEvents
+	sdl.reconnectEvents = nil
+	sdl.mu.Unlock()
+
+	for _, e := range events {
+		sdl.onReconnect(e.attempt, e.err)
+	}
+}
+
+// connectOnNew makes the first connection for a new logger.
+func (sdl *Sysdlog) connectOnNew() error {
+	var err error
+	for i := 0; i < max(sdl.newAttempts, 1); i++ {
+		if i > 0 {
+			time.Sleep(sdl.newDelay)
+		}
+
+		if err = sdl.connect(time.Time{}); err == nil {
+			return nil
+		}
+	}
+
+	if sdl.newAttempts > 0 && sdl.fallback != nil {
+		sdl.counters.setLastError(err)
+		return nil
+	}
+
+	return err
+}
+
+// jitteredDelay returns the delay to wait before the next reconnect.
+func (sdl *Sysdlog) jitteredDelay() time.Duration {
+	d := sdl.reconnectDelay
+	if d <= 0 || sdl.reconnectJitter <= 0 {
+		return d
+	}
+
+	// Scale the random number from [0, 1) to [-1, 1).
+	spread := (sdl.rand()*2 - 1) * sdl.reconnectJitter
+	return time.Duration(float64(d) * (1 + spread))
+}
+
+// waitReconnect sleeps for the reconnect delay, but not past the
+// deadline, unless it is zero.
+func (sdl *Sysdlog) waitReconnect(deadline time.Time) {
+	d := sdl.jitteredDelay()
+	if !deadline.IsZero() {
+		if left := time.Until(deadline); left < d {
+			d = left
+		}
+	}
+
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// defaultRand is the random source for reconnect jitter.
+var defaultRand = rand.Float64
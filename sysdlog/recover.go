@@ -0,0 +1,40 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "runtime/debug"
+
+// WithRepanic makes LogRecover panic again with the same value after
+// logging it, so the program still crashes, instead of swallowing the
+// panic.
+func WithRepanic() Option {
+	return func(sdl *Sysdlog) {
+		sdl.repanic = true
+	}
+}
+
+// LogRecover recovers from a panic and logs the panic value with
+// severity LOG_CRIT, along with the stack trace from where the panic
+// happened. It must be deferred directly, as in
+//
+//	defer sdl.LogRecover()
+//
+// since recover only works when called by a deferred function. The
+// panic is swallowed unless the logger was created with WithRepanic.
+// It does nothing if there is no panic.
+func (sdl *Sysdlog) LogRecover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	// The stack hasn't been unwound yet, so it still shows the
+	// panic.
+	sdl.Critf("panic: %v\n%s", v, debug.Stack())
+
+	if sdl != nil && sdl.repanic {
+		panic(v)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// WithReresolveInterval makes the logger check the socket path every
+// d and reconnect if the file there has changed, even if the current
+// connection still seems to work. This recovers from /dev/log being
+// recreated, for example when it is a bind mount or symlink that gets
+// replaced. The check only happens when a message is written and only
+// applies to unix sockets. It has no effect with WithNoReconnect.
+func WithReresolveInterval(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.reresolve = d
+	}
+}
+
+// isUnixNetwork reports whether the logger connects to a unix socket
+// on the file system.
+func (sdl *Sysdlog) isUnixNetwork() bool {
+	return strings.HasPrefix(sdl.network, "unix")
+}
+
+// resolved records the file currently at the socket path, after a
+// successful connect. The caller must hold the lock.
+func (sdl *Sysdlog) resolved() {
+	if sdl.reresolve <= 0 || !sdl.isUnixNetwork() {
+		return
+	}
+
+	sdl.lastResolve = sdl.now()
+	sdl.sockInfo, _ = os.Stat(sdl.raddr)
+}
+
+// maybeReresolve reconnects if the reresolve interval has passed and
+// the file at the socket path is no longer the one we connected to.
+// If the reconnect fails the current connection is kept. The caller
+// must hold the lock.
+func (sdl *Sysdlog) maybeReresolve() {
+	if sdl.reresolve <= 0 || sdl.noReconnect || sdl.conn == nil || !sdl.isUnixNetwork() {
+		return
+	}
+
+	if sdl.since(sdl.lastResolve) < sdl.reresolve {
+		return
+	}
+	sdl.lastResolve = sdl.now()
+
+	fi, err := os.Stat(sdl.raddr)
+	if err != nil || (sdl.sockInfo != nil && os.SameFile(fi, sdl.sockInfo)) {
+		return
+	}
+
+	err = sdl.connect(time.Time{})
+	sdl.noteReconnect(err)
+	if err == nil {
+		sdl.counters.reconnects.Add(1)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errRetryQueueFull is passed to the fallback stage for lines pushed
+// out of a full retry queue.
+var errRetryQueueFull = errors.New("sysdlog: retry queue is full")
+
+// WithRetryQueue keeps up to size messages that couldn't be written
+// to the socket and sends them again, in order and before any new
+// message, once the socket recovers. While messages are queued, new
+// messages are queued behind them. When the queue is full the oldest
+// message is pushed out to the fallback writer, or dropped if there
+// isn't one. A queued message counts as logged, so the call that
+// queued it doesn't return an error.
+func WithRetryQueue(size int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.retryQueue = &retryQueue{size: size}
+	}
+}
+
+// WithCloseTimeout bounds how long Close takes, counted from when it
+// is called. A write stuck on the socket, such as one waiting on a
+// journal that has stopped reading, is cut short at the timeout, so
+// Close doesn't wait on it forever for the lock. The messages still
+// in the retry queue are sent until d has passed or a send fails, and
+// the rest go to the fallback writer, or are dropped and reported in
+// Close's error if there isn't one. Without a timeout Close still
+// sends the queued messages in order until one fails, each send
+// limited only by WithRetryBudget.
+func WithCloseTimeout(d time.Duration) Option {
+	return func(sdl *Sysdlog) {
+		sdl.closeTimeout = d
+	}
+}
+
+// retryQueue holds formatted lines waiting to be sent again. It is
+// protected by the logger's lock.
+type retryQueue struct {
+	size    int
+	entries []retryEntry
+}
+
+// retryEntry is one queued line.
+type retryEntry struct {
+	s Severity
+	b []byte
+}
+
+// enqueueRetry adds a line to the retry queue, pushing the oldest
+// line on to the fallback stage if the queue is full. The new line is
+// queued either way, so it never fails; a pushed out line that is
+// dropped belongs to an earlier call and is only reported in Stats.
+// The caller must hold the lock.
+func (sdl *Sysdlog) enqueueRetry(s Severity, b []byte) error {
+	q := sdl.retryQueue
+
+	if len(q.entries) >= q.size {
+		oldest := q.entries[0]
+		q.entries = q.entries[1:]
+		if err := sdl.fallbackOrDrop(oldest.s, oldest.b, errRetryQueueFull); err != nil {
+			sdl.counters.setLastError(err)
+		}
+	}
+
+	if q.size > 0 {
+		q.entries = append(q.entries, retryEntry{s: s, b: b})
+	}
+	sdl.counters.pending.Store(int64(len(q.entries)))
+
+	return nil
+}
+
+// drainRetryQueue sends queued lines in order until the queue is
+// empty, which it reports as true, or a send fails. The caller must
+// hold the lock.
+func (sdl *Sysdlog) drainRetryQueue() bool {
+	q := sdl.retryQueue
+	if len(q.entries) == 0 {
+		return true
+	}
+
+	start := sdl.now()
+	for len(q.entries) > 0 {
+		e := q.entries[0]
+		if err := sdl.sendSocket(e.s, e.b); err != nil {
+			sdl.counters.pending.Store(int64(len(q.entries)))
+			return false
+		}
+		q.entries[0] = retryEntry{}
+		q.entries = q.entries[1:]
+	}
+
+	// Let go of the old backing array once it has been emptied.
+	q.entries = nil
+	sdl.counters.pending.Store(0)
+	sdl.recordFlush(start)
+	return true
+}
+
+// drainOnClose sends what is left in the retry queue before Close
+// shuts the connection, within the close timeout. Lines that can't be
+// sent are handed to the fallback stage, and the error reports how
+// many of them were dropped. The caller must hold the lock.
+func (sdl *Sysdlog) drainOnClose() error {
+	q := sdl.retryQueue
+	if q == nil || len(q.entries) == 0 {
+		return nil
+	}
+
+	// Once the close timeout has passed, every send fails at once.
+	for len(q.entries) > 0 {
+		e := q.entries[0]
+		if err := sdl.sendSocket(e.s, e.b); err != nil {
+			break
+		}
+		q.entries[0] = retryEntry{}
+		q.entries = q.entries[1:]
+	}
+
+	dropped := 0
+	for _, e := range q.entries {
+		if sdl.fallbackOrDrop(e.s, e.b, ErrClosed) != nil {
+			dropped++
+		}
+	}
+	q.entries = nil
+	sdl.counters.pending.Store(0)
+
+	if dropped > 0 {
+		return fmt.Errorf("sysdlog: %d queued messages dropped on close", dropped)
+	}
+
+	return nil
+}
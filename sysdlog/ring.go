@@ -0,0 +1,93 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithRingBuffer keeps the last n messages in memory, whatever their
+// severity and whether or not they were sent, so they can be written
+// out with Dump after a crash.
+func WithRingBuffer(n int) Option {
+	return func(sdl *Sysdlog) {
+		if n < 0 {
+			n = 0
+		}
+		sdl.ring = &ring{entries: make([]ringEntry, n)}
+	}
+}
+
+// Dump writes the messages held by the ring buffer to w, oldest first,
+// one per line with the time they were logged. It does nothing if
+// the logger has no ring buffer. Dump doesn't take the logger's lock,
+// so it is safe to call from a panic handler even while another
+// goroutine is stuck logging.
+func (sdl *Sysdlog) Dump(w io.Writer) error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	if sdl.ring == nil {
+		return nil
+	}
+
+	for _, e := range sdl.ring.snapshot() {
+		m := strings.TrimSuffix(e.m, "\n")
+		if _, err := fmt.Fprintf(w, "%s %s %s\n", e.t.Format(time.RFC3339Nano), e.s, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ring is a fixed size buffer of the most recent messages. It has its
+// own lock so it can be read without the logger's.
+type ring struct {
+	mu      sync.Mutex
+	entries []ringEntry
+	next    int
+	full    bool
+}
+
+// ringEntry is one message in the ring.
+type ringEntry struct {
+	t time.Time
+	s Severity
+	m string
+}
+
+// add records a message, replacing the oldest once the ring is full.
+func (r *ring) add(t time.Time, s Severity, m string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) == 0 {
+		return
+	}
+
+	r.entries[r.next] = ringEntry{t: t, s: s, m: m}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns a copy of the messages in the ring, oldest first.
+func (r *ring) snapshot() []ringEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]ringEntry(nil), r.entries[:r.next]...)
+	}
+
+	return append(append([]ringEntry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
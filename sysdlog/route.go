@@ -0,0 +1,82 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "reflect"
+
+// Logger is anything that can log a message at a severity. *Sysdlog
+// implements it, so one Sysdlog can route messages to another.
+type Logger interface {
+	Log(s Severity, m string) error
+}
+
+// Log logs a message with the given severity.
+func (sdl *Sysdlog) Log(s Severity, m string) error {
+	_, err := sdl.writeRetry(s, m)
+	return err
+}
+
+// route sends messages at or above a severity to an extra sink.
+type route struct {
+	min  Severity
+	sink Logger
+}
+
+// RouteSeverity sends every message at least as severe as min to sink
+// as well as to the systemd logger. A message matching several routes
+// goes to each of their sinks, and only once to a sink routed at more
+// than one severity. Adding the same sink with the same severity again
+// has no effect, and neither does routing a logger to itself. A
+// message is only routed once: a *Sysdlog sink logs it without passing
+// it on to its own routes, so loggers routed to each other don't
+// loop. Sinks are called after the logger's lock is released, in the
+// order they were added, and their errors are ignored.
+func (sdl *Sysdlog) RouteSeverity(min Severity, sink Logger) {
+	if sdl == nil || sink == nil {
+		return
+	}
+
+	if self, ok := sink.(*Sysdlog); ok && self == sdl {
+		return
+	}
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	for _, r := range sdl.routes {
+		if r.min == min && sameLogger(r.sink, sink) {
+			return
+		}
+	}
+
+	sdl.routes = append(sdl.routes, route{min: min, sink: sink})
+}
+
+// matchRoutes returns the sinks that a message with severity s should
+// be sent to, each of them once. The caller must hold the lock.
+func (sdl *Sysdlog) matchRoutes(s Severity) []Logger {
+	var sinks []Logger
+routes:
+	for _, r := range sdl.routes {
+		if !s.AtLeast(r.min) {
+			continue
+		}
+		for _, sink := range sinks {
+			if sameLogger(sink, r.sink) {
+				continue routes
+			}
+		}
+		sinks = append(sinks, r.sink)
+	}
+
+	return sinks
+}
+
+// sameLogger reports whether a and b are the same logger, without
+// panicking on types that can't be compared.
+func sameLogger(a, b Logger) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta.Comparable() && a == b
+}
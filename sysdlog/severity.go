@@ -0,0 +1,258 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// severityNames maps the common names for each severity to the
+// severity itself. The keys are all lower case.
+var severityNames = map[string]Severity{
+	"emerg":         LOG_EMERG,
+	"emergency":     LOG_EMERG,
+	"panic":         LOG_EMERG,
+	"alert":         LOG_ALERT,
+	"crit":          LOG_CRIT,
+	"critical":      LOG_CRIT,
+	"fatal":         LOG_CRIT,
+	"err":           LOG_ERR,
+	"error":         LOG_ERR,
+	"warn":          LOG_WARNING,
+	"warning":       LOG_WARNING,
+	"notice":        LOG_NOTICE,
+	"info":          LOG_INFO,
+	"informational": LOG_INFO,
+	"debug":         LOG_DEBUG,
+}
+
+// ParseSeverity returns the severity with the given name. Names are
+// case insensitive and include the usual abbreviations ("warn",
+// "err", "crit") as well as the numeric levels "0" through "7".
+func ParseSeverity(name string) (Severity, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if s, ok := severityNames[n]; ok {
+		return s, nil
+	}
+
+	if len(n) == 1 && n[0] >= '0' && n[0] <= '7' {
+		return Severity("<" + n + ">"), nil
+	}
+
+	return "", fmt.Errorf("sysdlog: unknown severity %q", name)
+}
+
+// detectLevelToken looks for a leading level token like "[WARN]" or
+// "ERROR:" at the start of m. If one is found, its severity and the
+// rest of the message are returned. Only names count as tokens, not
+// the numeric levels ParseSeverity accepts, which would match
+// messages like "1: retrying" or "[0] first item".
+func detectLevelToken(m string) (Severity, string, bool) {
+	var token, rest string
+
+	switch {
+	case strings.HasPrefix(m, "["):
+		end := strings.IndexByte(m, ']')
+		if end < 0 {
+			return "", m, false
+		}
+		token, rest = m[1:end], m[end+1:]
+	default:
+		end := strings.IndexByte(m, ':')
+		if end < 0 || strings.ContainsAny(m[:end], " \t") {
+			return "", m, false
+		}
+		token, rest = m[:end], m[end+1:]
+	}
+
+	s, ok := severityNames[strings.ToLower(strings.TrimSpace(token))]
+	if !ok {
+		return "", m, false
+	}
+
+	return s, strings.TrimLeft(rest, " \t"), true
+}
+
+// level returns the numeric level of the severity, or LOG_ERR's level
+// if the severity isn't one of the known values.
+func (s Severity) level() int {
+	if s.valid() {
+		return int(s[1] - '0')
+	}
+
+	return 3
+}
+
+// severityFromLevel returns the Severity for a numeric level between
+// 0 and 7.
+func severityFromLevel(l int) Severity {
+	return Severity("<" + string(rune('0'+l&severityMask)) + ">")
+}
+
+// clamp lowers s to the severity ceiling if it is more severe. The
+// caller must hold the lock.
+func (sdl *Sysdlog) clamp(s Severity) Severity {
+	if sdl.ceiling != "" && s.MoreSevereThan(sdl.ceiling) {
+		return sdl.ceiling
+	}
+
+	return s
+}
+
+// enabled reports whether a message with severity s passes the
+// minimum severity filter, taking any boost into account. The caller
+// must hold the lock.
+func (sdl *Sysdlog) enabled(s Severity) bool {
+	min := sdl.minSeverity
+	if sdl.boost != "" {
+		if sdl.now().Before(sdl.boostUntil) {
+			min = sdl.boost
+		} else {
+			sdl.boost = ""
+		}
+	}
+
+	return min == "" || s.AtLeast(min)
+}
+
+// BoostSeverity temporarily sets the minimum severity to s for d,
+// for example to see debug messages from a live process for a minute.
+// The usual minimum applies again once d has passed on the logger's
+// clock. A later call replaces an earlier boost, and a zero d ends
+// any boost early. It is safe to call while other goroutines are
+// logging.
+func (sdl *Sysdlog) BoostSeverity(s Severity, d time.Duration) {
+	if sdl == nil {
+		return
+	}
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	sdl.boost = s
+	sdl.boostUntil = sdl.now().Add(d)
+}
+
+// MoreSevereThan reports whether s is more severe than o, so
+// LOG_CRIT is more severe than LOG_ERR. Severities that aren't one of
+// the eight known values compare as LOG_ERR.
+func (s Severity) MoreSevereThan(o Severity) bool {
+	return s.level() < o.level()
+}
+
+// AtLeast reports whether s is at least as severe as o, so a message
+// at s passes a minimum severity of o.
+func (s Severity) AtLeast(o Severity) bool {
+	return s.level() <= o.level()
+}
+
+// valid reports whether s is one of the eight known severities.
+func (s Severity) valid() bool {
+	return len(s) == 3 && s[0] == '<' && s[1] >= '0' && s[1] <= '7' && s[2] == '>'
+}
+
+// severityShortNames are the names for each level, as used by syslog.
+var severityShortNames = [...]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// levelTokens are the level names used by WithSeverityInMessage. They
+// are the spellings most log parsers expect, and ParseSeverity
+// accepts each of them.
+var levelTokens = [...]string{"EMERG", "ALERT", "CRIT", "ERROR", "WARN", "NOTICE", "INFO", "DEBUG"}
+
+// name returns the short name of the severity, like "warning".
+func (s Severity) name() string {
+	return severityShortNames[s.level()]
+}
+
+// MarshalText implements encoding.TextMarshaler using the short name
+// of the severity, like "warning". The zero Severity, which means
+// unset, is encoded as an empty string.
+func (s Severity) MarshalText() ([]byte, error) {
+	if s == "" {
+		return []byte{}, nil
+	}
+
+	if !s.valid() {
+		return nil, fmt.Errorf("sysdlog: invalid severity %q", string(s))
+	}
+
+	return []byte(s.name()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts any
+// name ParseSeverity does, and an empty string for the zero Severity.
+func (s *Severity) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*s = ""
+		return nil
+	}
+
+	v, err := ParseSeverity(string(b))
+	if err != nil {
+		return err
+	}
+
+	*s = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding the severity as a
+// JSON string of its name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	b, err := s.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It expects a JSON string
+// holding any name ParseSeverity accepts.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return fmt.Errorf("sysdlog: severity must be a string: %w", err)
+	}
+
+	return s.UnmarshalText([]byte(name))
+}
+
+// ColorReset is the ANSI escape sequence that ends the color started
+// by Severity.Color.
+const ColorReset = "\x1b[0m"
+
+// severityColors are the ANSI colors for each level.
+var severityColors = [...]string{
+	"\x1b[1;35m", // emerg: bold magenta
+	"\x1b[1;31m", // alert: bold red
+	"\x1b[1;31m", // crit: bold red
+	"\x1b[31m",   // err: red
+	"\x1b[33m",   // warning: yellow
+	"\x1b[36m",   // notice: cyan
+	"\x1b[32m",   // info: green
+	"\x1b[90m",   // debug: gray
+}
+
+// Color returns the ANSI escape sequence for the conventional
+// terminal color of the severity, for local text output such as a
+// terminal. Follow the colored text with ColorReset. It is never used
+// for messages sent to the journal.
+func (s Severity) Color() string {
+	return severityColors[s.level()]
+}
+
+// severityEmoji are the emoji for each level.
+var severityEmoji = [...]string{"💥", "🚨", "🔴", "❌", "⚠️", "🔵", "ℹ️", "🐛"}
+
+// Emoji returns an emoji for the severity, like "🔴" for LOG_CRIT, for
+// local text output. It is never used for messages sent to the
+// journal.
+func (s Severity) Emoji() string {
+	return severityEmoji[s.level()]
+}
@@ -0,0 +1,98 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultSignalFlushTimeout bounds the flush done by FlushOnSignal
+// when there is no close timeout.
+const defaultSignalFlushTimeout = time.Second
+
+// FlushOnSignal calls FlushSync when the process receives one of
+// sigs, or SIGINT or SIGTERM if none are given, so messages held by
+// WithCoalesceWindow or queued by WithRetryQueue aren't lost when the
+// process is told to exit. The signal isn't swallowed: once the logger
+// is flushed the handler is removed and the signal is sent to the
+// process again, which then handles it as it would have without the
+// logger. The flush is given the WithCloseTimeout timeout, or one
+// second without one, and the signal is sent again when it runs out
+// even if the flush is stuck on the socket. Where a process can't
+// signal itself, as on Windows, it exits with status 1 instead.
+// Programs that handle the signals themselves should call FlushSync
+// from their own handler instead, since they would receive the signal
+// twice. The returned function removes the handler.
+func (sdl *Sysdlog) FlushOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			sdl.flushWithin(sdl.signalFlushTimeout())
+			signal.Stop(ch)
+			if err := raise(sig); err != nil {
+				os.Exit(1)
+			}
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// signalFlushTimeout returns how long FlushOnSignal waits for the
+// flush.
+func (sdl *Sysdlog) signalFlushTimeout() time.Duration {
+	if sdl != nil && sdl.closeTimeout > 0 {
+		return sdl.closeTimeout
+	}
+
+	return defaultSignalFlushTimeout
+}
+
+// flushWithin runs FlushSync, giving up on waiting for it after d. The
+// flush carries on in the background if it takes longer.
+func (sdl *Sysdlog) flushWithin(d time.Duration) {
+	flushed := make(chan struct{})
+	go func() {
+		sdl.FlushSync()
+		close(flushed)
+	}()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-flushed:
+	case <-t.C:
+	}
+}
+
+// raise sends sig to the current process.
+func raise(sig os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+
+	return p.Signal(sig)
+}
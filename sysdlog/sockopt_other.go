@@ -0,0 +1,17 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+//go:build !unix
+
+package sysdlog
+
+import (
+	"errors"
+	"net"
+)
+
+// setSendBuffer isn't supported on this platform.
+func setSendBuffer(conn net.Conn, n int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,40 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+//go:build unix
+
+package sysdlog
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// setSendBuffer sets SO_SNDBUF on the socket underlying conn.
+func setSendBuffer(conn net.Conn, n int) error {
+	return controlConn(conn, func(fd int) error {
+		return syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_SNDBUF, n)
+	})
+}
+
+// controlConn runs f with the file descriptor underlying conn.
+func controlConn(conn net.Conn, f func(fd int) error) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+	if err := rc.Control(func(fd uintptr) { ferr = f(int(fd)) }); err != nil {
+		return err
+	}
+
+	return ferr
+}
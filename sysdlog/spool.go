@@ -0,0 +1,119 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// spoolTimeFormat names spool files so they sort in the order they
+// were written.
+const spoolTimeFormat = "20060102T150405.000000000"
+
+// WithSpoolDir makes the fallback writer a spool of gzip compressed
+// files in dir, for outages too long for WithRetryQueue. Messages that
+// can't be sent are written there, already formatted, and a new file
+// is started once the current one holds maxBytes of uncompressed
+// messages. Files are named spool-<time>-<n>.log.gz, so sorting the
+// names gives the order they were written in, and each one can be
+// sent once the journal is back with ReplayFile, which reads gzip
+// files. The spool is the fallback writer, so it can't be combined
+// with WithFallback. Every message is flushed to the file as it is
+// written, and Close finishes the current file. A maxBytes of zero
+// means files are never rotated.
+func WithSpoolDir(dir string, maxBytes int64) Option {
+	return func(sdl *Sysdlog) {
+		sdl.spool = &spool{dir: dir, max: maxBytes, now: sdl.now}
+		sdl.fallback = sdl.spool
+	}
+}
+
+// spool writes messages to rotating gzip files. It is protected by
+// the logger's lock.
+type spool struct {
+	dir string
+	max int64
+	now func() time.Time
+
+	f     *os.File
+	gz    *gzip.Writer
+	size  int64
+	files int
+}
+
+// Write adds one formatted message to the current spool file,
+// starting a new file first if the current one is full.
+func (sp *spool) Write(b []byte) (int, error) {
+	if sp.f != nil && sp.max > 0 && sp.size > 0 && sp.size+int64(len(b)) > sp.max {
+		if err := sp.close(); err != nil {
+			return 0, err
+		}
+	}
+
+	if sp.f == nil {
+		if err := sp.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	// Lines are how ReplayFile tells messages apart.
+	line := b
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line = append(line[:len(line):len(line)], '\n')
+	}
+
+	if _, err := sp.gz.Write(line); err != nil {
+		return 0, err
+	}
+	sp.size += int64(len(line))
+
+	if err := sp.gz.Flush(); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// Flush flushes the current spool file to disk.
+func (sp *spool) Flush() error {
+	if sp.f == nil {
+		return nil
+	}
+
+	if err := sp.gz.Flush(); err != nil {
+		return err
+	}
+
+	return sp.f.Sync()
+}
+
+// open starts a new spool file.
+func (sp *spool) open() error {
+	sp.files++
+	name := filepath.Join(sp.dir, fmt.Sprintf("spool-%s-%06d.log.gz", sp.now().UTC().Format(spoolTimeFormat), sp.files))
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	sp.f, sp.gz, sp.size = f, gzip.NewWriter(f), 0
+	return nil
+}
+
+// close finishes the current spool file, if there is one.
+func (sp *spool) close() error {
+	if sp.f == nil {
+		return nil
+	}
+
+	err := errors.Join(sp.gz.Close(), sp.f.Close())
+	sp.f, sp.gz = nil, nil
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of a logger's counters.
+type Stats struct {
+	// SocketWrites and SocketFailures count messages that were
+	// and weren't written to the systemd logger.
+	SocketWrites   uint64
+	SocketFailures uint64
+
+	// FallbackWrites and FallbackFailures count messages that were
+	// and weren't written to the fallback writer after the socket
+	// failed.
+	FallbackWrites   uint64
+	FallbackFailures uint64
+
+	// Dropped counts messages that couldn't be delivered anywhere
+	// or were dropped on purpose, such as by an entry hook.
+	Dropped uint64
+
+	// Suppressed counts repeated messages that were deduplicated.
+	Suppressed uint64
+
+	// RateLimited counts messages dropped by the rate limit.
+	RateLimited uint64
+
+	// BySeverity counts delivered messages by the numeric level of
+	// their severity, so BySeverity[4] is the number of warnings.
+	BySeverity [8]uint64
+
+	// BytesWritten is the number of bytes written to the socket.
+	BytesWritten uint64
+
+	// Reconnects counts how many times the connection was
+	// re-established after the first connect.
+	Reconnects uint64
+
+	// LastError is the most recent error from the socket or the
+	// fallback writer, or nil if there hasn't been one.
+	LastError error
+
+	// PendingQueue is the number of messages waiting in the retry
+	// queue.
+	PendingQueue int
+
+	// LastFlush is when the retry queue was last emptied, or a
+	// FlushSync last succeeded, by the logger's clock. FlushDuration
+	// is how long that took. Both are zero until it first happens.
+	LastFlush     time.Time
+	FlushDuration time.Duration
+
+	// Degraded reports whether WithSelfHealing has taken the socket
+	// out of use after repeated probe failures.
+	Degraded bool
+}
+
+// counters holds the live values behind Stats. They are updated
+// atomically so Stats never needs the logger's lock.
+type counters struct {
+	socketWrites     atomic.Uint64
+	socketFailures   atomic.Uint64
+	fallbackWrites   atomic.Uint64
+	fallbackFailures atomic.Uint64
+	dropped          atomic.Uint64
+	suppressed       atomic.Uint64
+	rateLimited      atomic.Uint64
+	bySeverity       [8]atomic.Uint64
+	bytesWritten     atomic.Uint64
+	reconnects       atomic.Uint64
+	lastError        atomic.Pointer[error]
+	pending          atomic.Int64
+	lastFlush        atomic.Pointer[time.Time]
+	flushDuration    atomic.Int64
+}
+
+// setLastError records err as the most recent error.
+func (c *counters) setLastError(err error) {
+	c.lastError.Store(&err)
+}
+
+// recordFlush records a flush that started at start and has just
+// finished.
+func (sdl *Sysdlog) recordFlush(start time.Time) {
+	t := sdl.now()
+	sdl.counters.lastFlush.Store(&t)
+	sdl.counters.flushDuration.Store(int64(t.Sub(start)))
+}
+
+// Stats returns a snapshot of the logger's counters. It is safe to
+// call at any time, including while other goroutines are logging.
+// Each counter is read atomically, but a message being logged while
+// the snapshot is taken may be reflected in some counters and not yet
+// in others. A nil logger has all zero stats.
+func (sdl *Sysdlog) Stats() Stats {
+	if sdl == nil {
+		return Stats{}
+	}
+
+	c := &sdl.counters
+
+	st := Stats{
+		SocketWrites:     c.socketWrites.Load(),
+		SocketFailures:   c.socketFailures.Load(),
+		FallbackWrites:   c.fallbackWrites.Load(),
+		FallbackFailures: c.fallbackFailures.Load(),
+		Dropped:          c.dropped.Load(),
+		Suppressed:       c.suppressed.Load(),
+		RateLimited:      c.rateLimited.Load(),
+		BytesWritten:     c.bytesWritten.Load(),
+		Reconnects:       c.reconnects.Load(),
+		PendingQueue:     int(c.pending.Load()),
+		FlushDuration:    time.Duration(c.flushDuration.Load()),
+		Degraded:         sdl.degraded.Load(),
+	}
+
+	for i := range c.bySeverity {
+		st.BySeverity[i] = c.bySeverity[i].Load()
+	}
+
+	if err := c.lastError.Load(); err != nil {
+		st.LastError = *err
+	}
+
+	if t := c.lastFlush.Load(); t != nil {
+		st.LastFlush = *t
+	}
+
+	return st
+}
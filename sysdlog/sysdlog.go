@@ -6,14 +6,37 @@
 // service. It connects via '/dev/log' and can include priority
 // messages. It also implements the io.Writer interface so that it can
 // be used as the default logger.
+//
+// Logging methods, Write and Close may be called on a nil *Sysdlog.
+// They return ErrNilLogger instead of panicking, so a logger whose
+// construction failed doesn't crash the program.
+//
+// A *Sysdlog is safe for use by multiple goroutines. Messages are
+// formatted and sent one at a time under the logger's lock, so they
+// never interleave, and the setters (SetPrefix, SetMinSeverity,
+// SetSeverityRemap, BoostSeverity, RouteSeverity) take the same lock
+// and apply from the next message on. Close may be called while
+// other goroutines are logging; later messages get ErrClosed, or are
+// dropped with WithDropOnClosed. Stats and Dump don't take the
+// logger's lock, so they won't block behind a slow send. User
+// callbacks such as entry hooks and transforms run with the lock held
+// and must not log to the same logger; prefix functions and routed
+// sinks run without it.
 package sysdlog
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Severity is a standard linux logging severity. They represent that
@@ -31,12 +54,153 @@ const (
 	LOG_DEBUG   Severity = "<7>"
 )
 
+// ErrClosed is returned when logging to a Sysdlog that has been
+// closed.
+var ErrClosed = errors.New("sysdlog: logger is closed")
+
+// ErrNilLogger is returned when logging to a nil *Sysdlog, which
+// usually means the error from New was ignored.
+var ErrNilLogger = errors.New("sysdlog: logger is nil")
+
 // Sysdlog is a connection to the systemd logger.
 type Sysdlog struct {
-	prefix string
+	prefix     string
+	prefixFunc func() string
+	tag        string
+	pid        int
+	severity   Severity
+	facility   Priority
+
+	network string
+	raddr   string
+	conn    net.Conn
+	mu      sync.Mutex
+	remap   map[Severity]Severity
+
+	detectors []func(m string) (Severity, string, bool)
+
+	sndbuf            int
+	ignoreUnsupported bool
+
+	retryBudget time.Duration
+
+	minSeverity Severity
+	rawPRI      bool
+
+	fallback io.Writer
+	counters counters
+
+	clock func() time.Time
+
+	closed       bool
+	dropOnClosed bool
+
+	transform func(s Severity, m string) string
+	replacer  *strings.Replacer
+
+	reresolve   time.Duration
+	lastResolve time.Time
+	sockInfo    os.FileInfo
+
+	maxLen   int
+	maxRunes int
+
+	reconnectDelay  time.Duration
+	reconnectJitter float64
+	rand            func() float64
+
+	hooks []func(e *Entry) bool
+
+	dedupe *dedupe
+
+	sequence bool
+	seq      atomic.Uint64
+
+	routes []route
+
+	prefixPolicy PrefixPolicy
+	maxPrefixLen int
+
+	ring *ring
+
+	retryQueue *retryQueue
+
+	ceiling Severity
+
+	severityRenderer func(s Severity) string
+
+	newAttempts int
+	newDelay    time.Duration
+
+	durationFormat func(d time.Duration) string
+
+	creds    *Credentials
+	passCred bool
+
+	limiter *tokenBucket
+
+	autoSink bool
+	textSink io.Writer
+
+	boost      Severity
+	boostUntil time.Time
+
+	emptyPolicy EmptyMessagePolicy
+
+	connType ConnType
+	stream   bool
+
+	writeHook func(b []byte)
 
-	conn net.Conn
-	mu   sync.Mutex
+	embedPriority bool
+
+	udpMaxPacket int
+
+	mirrorAbove Severity
+	mirror      io.Writer
+
+	closeTimeout time.Duration
+	closeBy      atomic.Int64
+	liveConn     atomic.Pointer[net.Conn]
+
+	permCheck   bool
+	permAllowed os.FileMode
+	permStrict  bool
+
+	keepAlive time.Duration
+
+	repanic bool
+
+	onReconnect      func(attempt int, err error)
+	reconnectAttempt int
+	reconnectEvents  []reconnectEvent
+
+	noReconnect bool
+
+	levelToken bool
+
+	resend   bool
+	lastSent []byte
+
+	healInterval  time.Duration
+	healThreshold int
+	healFailures  int
+	degraded      atomic.Bool
+	stopHeal      chan struct{}
+
+	blocklist []*regexp.Regexp
+	allowlist []*regexp.Regexp
+
+	instanceID    string
+	instanceToken bool
+
+	spool *spool
+
+	namespace string
+
+	coalesce *coalescer
+
+	envelopeHeader func(payloadLen int) []byte
 }
 
 // New creates a new Sysdlog. All messages sent to this logger will
@@ -44,23 +208,71 @@ type Sysdlog struct {
 // that have the form "prefix: " or "[prefix]: ". If you are using a
 // prefix like that it will likely be stripped off in the
 // log. Instead, you can try something like "<prefix> " or "[prefix]
-// ".
-func New(prefix string) (*Sysdlog, error) {
-	sdl := &Sysdlog{
-		prefix: prefix,
+// ". WithPrefixPolicy can reject or rewrite such prefixes. Any
+// options given are applied before the connection is made.
+func New(prefix string, opts ...Option) (*Sysdlog, error) {
+	sdl := newSysdlog()
+	sdl.prefix = prefix
+
+	return sdl.open(opts)
+}
+
+// newSysdlog returns a Sysdlog with the default settings for the
+// local systemd logger.
+func newSysdlog() *Sysdlog {
+	return &Sysdlog{
+		severity: LOG_ERR,
+		clock:    time.Now,
+		rand:     defaultRand,
+		network:  "unixgram",
+		raddr:    "/dev/log",
+
+		udpMaxPacket: defaultUDPMaxPacketSize,
+	}
+}
+
+// open applies the options, validates them and makes the first
+// connection.
+func (sdl *Sysdlog) open(opts []Option) (*Sysdlog, error) {
+	for _, opt := range opts {
+		opt(sdl)
+	}
+
+	if err := sdl.validate(); err != nil {
+		return nil, err
+	}
+
+	if sdl.namespace != "" {
+		sdl.raddr = sdl.namespaceSocket()
+	}
+
+	p, err := sdl.checkPrefix(sdl.prefix)
+	if err != nil {
+		return nil, err
 	}
+	sdl.prefix = p
 
-	if err := sdl.connect(); err != nil {
+	if err := sdl.setInstanceID(); err != nil {
 		return nil, err
 	}
 
+	if sdl.autoSink && sdl.isUnixNetwork() && !socketAvailable(sdl.raddr) {
+		sdl.textSink = os.Stderr
+		return sdl, nil
+	}
+
+	if err := sdl.connectOnNew(); err != nil {
+		return nil, err
+	}
+	sdl.startHealing()
+
 	return sdl, nil
 }
 
 // NewLogger creates a log.Logger whose output is written to a systemd
-// logger with the given flag.
-func NewLogger(flags int) (*log.Logger, error) {
-	s, err := New("")
+// logger with the given flag. The options are passed along to New.
+func NewLogger(flags int, opts ...Option) (*log.Logger, error) {
+	s, err := New("", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -68,15 +280,98 @@ func NewLogger(flags int) (*log.Logger, error) {
 	return log.New(s, "", flags), nil
 }
 
-// Close closes the open connection to the systemd logger.
-func (sdl *Sysdlog) Close() {
-	sdl.conn.Close()
+// Close closes the open connection to the systemd logger. Messages
+// logged after Close fail with ErrClosed, or are dropped if the logger
+// was created with WithDropOnClosed. Closing an already closed logger
+// returns ErrClosed. Messages still in the retry queue are sent
+// first, within the limit set by WithCloseTimeout.
+func (sdl *Sysdlog) Close() error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	// A write stuck on the socket holds the lock, so it is cut short
+	// at the close timeout to let Close in.
+	if sdl.closeTimeout > 0 {
+		sdl.closeBy.CompareAndSwap(0, time.Now().Add(sdl.closeTimeout).UnixNano())
+		if c := sdl.liveConn.Load(); c != nil {
+			(*c).SetWriteDeadline(sdl.closeDeadline(time.Time{}))
+		}
+	}
+
+	sdl.mu.Lock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return ErrClosed
+	}
+	sdl.closed = true
+	sdl.stopHealing()
+	sdl.flushDedupe()
+	sdl.flushHeld()
+
+	err := sdl.drainOnClose()
+	if sdl.spool != nil {
+		err = errors.Join(err, sdl.spool.close())
+	}
+	if sdl.conn == nil {
+		return err
+	}
+
+	err = errors.Join(err, sdl.conn.Close())
+	sdl.conn = nil
+	sdl.liveConn.Store(nil)
+	return err
+}
+
+// afterClose is the result of logging n bytes once the logger is
+// closed. The caller must hold the lock.
+func (sdl *Sysdlog) afterClose(n int) (int, error) {
+	if sdl.dropOnClosed {
+		sdl.counters.dropped.Add(1)
+		return n, nil
+	}
+
+	return 0, ErrClosed
 }
 
-// Write writes the given bytes to the logger using the severity
-// LOG_ERR.
+// Write writes the given bytes to the logger using the default
+// severity, which is LOG_ERR unless the logger was created with
+// Dial. If level detection is enabled, a leading level token
+// selects the severity instead and is removed from the message. If
+// the message is truncated, the number of bytes kept is returned with
+// io.ErrShortWrite.
 func (sdl *Sysdlog) Write(b []byte) (int, error) {
-	return sdl.writeRetry(LOG_ERR, string(b))
+	if sdl == nil {
+		return 0, ErrNilLogger
+	}
+
+	s, m := sdl.detectLevel(string(b))
+	n, err := sdl.writeRetry(s, m)
+	if err != nil {
+		return 0, err
+	}
+
+	// Count a stripped level token as accepted.
+	n += len(b) - len(m)
+	if n < len(b) {
+		return n, io.ErrShortWrite
+	}
+
+	return n, nil
+}
+
+// detectLevel returns the severity for a message passed to Write,
+// using level detection if it is enabled, and the message to log. The
+// detectors are tried in the order their options were given.
+func (sdl *Sysdlog) detectLevel(m string) (Severity, string) {
+	for _, detect := range sdl.detectors {
+		if s, rest, ok := detect(m); ok {
+			return s, rest
+		}
+	}
+
+	return sdl.severity, m
 }
 
 // Emerg logs a message with severity LOG_EMERG.
@@ -178,46 +473,457 @@ func (sdl *Sysdlog) Debugf(format string, v ...interface{}) error {
 // writeRetry attempts to write the given log message and is capable
 // of reconnecting to a closed connection.
 func (sdl *Sysdlog) writeRetry(s Severity, m string) (int, error) {
+	return sdl.writeRouted(s, m, true)
+}
+
+// writeRouted does the work of writeRetry, only passing the message
+// on to routed sinks if route is true.
+func (sdl *Sysdlog) writeRouted(s Severity, m string, route bool) (int, error) {
+	if sdl == nil {
+		return 0, ErrNilLogger
+	}
+
+	// The prefix function is user code, so run it before taking the
+	// lock.
+	var prefix string
+	if sdl.prefixFunc != nil {
+		prefix = sdl.prefixFunc()
+	}
+
+	// Routed sinks are called once the lock is released, since they
+	// may be slow or log back to this logger.
+	var sinks []Logger
+	var rs Severity
+	var rm string
+	defer func() {
+		for _, sink := range sinks {
+			// A routed message isn't routed again, so routes
+			// between loggers can't loop.
+			if l, ok := sink.(*Sysdlog); ok {
+				l.writeRouted(rs, rm, false)
+				continue
+			}
+			sink.Log(rs, rm)
+		}
+	}()
+
 	sdl.mu.Lock()
-	defer sdl.mu.Unlock()
+	defer sdl.unlock()
+
+	if sdl.closed {
+		return sdl.afterClose(len(m))
+	}
+
+	if sdl.prefixFunc == nil {
+		prefix = sdl.prefix
+	}
+
+	// Swap the logical severity for the effective one.
+	if r, ok := sdl.remap[s]; ok {
+		s = r
+	}
+	s = sdl.clamp(s)
+
+	if sdl.ring != nil {
+		sdl.ring.add(sdl.now(), s, m)
+	}
+
+	if !sdl.enabled(s) {
+		return len(m), nil
+	}
+
+	n := len(m)
+	m, ok := sdl.handleEmpty(m)
+	if !ok {
+		return n, nil
+	}
+	if !sdl.allowed(m) {
+		sdl.counters.dropped.Add(1)
+		return n, nil
+	}
+	if len(sdl.hooks) > 0 {
+		e := Entry{Severity: s, Message: m}
+		if sdl.runHooks(&e) {
+			sdl.counters.dropped.Add(1)
+			return n, nil
+		}
+		s, m = sdl.clamp(e.Severity), e.Message
+	}
+
+	if sdl.dedupe != nil {
+		drop, sums := sdl.dedupe.check(s, prefix, m, sdl.now())
+		sdl.logSummaries(sums)
+		if drop {
+			sdl.counters.suppressed.Add(1)
+			return n, nil
+		}
+	}
+
+	if sdl.limiter != nil && !sdl.limiter.allow(sdl.now()) {
+		sdl.counters.rateLimited.Add(1)
+		return n, nil
+	}
+
+	if route {
+		sinks, rs, rm = sdl.matchRoutes(s), s, m
+	}
+
+	// Only report fewer bytes than given if the message was truncated.
+	if t, kept := sdl.truncate(m); t != m {
+		m = t
+		if kept < n {
+			n = kept
+		}
+	}
+
+	if sdl.coalesce != nil {
+		sdl.hold(s, prefix, m)
+		return n, nil
+	}
+
+	if err := sdl.deliver(s, sdl.format(s, prefix, m)); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// format builds the line that is sent to the logger for a message.
+func (sdl *Sysdlog) format(s Severity, prefix, m string) []byte {
+	m = strings.TrimSuffix(m, "\n")
+	if sdl.transform != nil {
+		m = strings.TrimSuffix(sdl.transform(s, m), "\n")
+	}
+	m = sdl.sanitize(m)
+	if sdl.embedPriority {
+		m += fmt.Sprintf(" PRIORITY=%d", s.level())
+	}
+
+	if sdl.textSink != nil {
+		return sdl.formatText(s, prefix, sdl.renderTokens(s), m)
+	}
+
+	return []byte(fmt.Sprintf("%s %s%s%s%s\n", sdl.renderSeverity(s), sdl.renderTag(), prefix, sdl.renderTokens(s), m))
+}
+
+// renderTokens returns the tokens that go between the prefix and the
+// message, each followed by a space.
+func (sdl *Sysdlog) renderTokens(s Severity) string {
+	var b strings.Builder
+	if sdl.levelToken && sdl.textSink == nil {
+		fmt.Fprintf(&b, "[%s] ", levelTokens[s.level()])
+	}
+	if sdl.instanceToken {
+		fmt.Fprintf(&b, "logger_id=%s ", sdl.instanceID)
+	}
+	if sdl.sequence {
+		fmt.Fprintf(&b, "seq=%d ", sdl.seq.Add(1))
+	}
+
+	return b.String()
+}
+
+// sanitize applies the configured character replacements to a message
+// that has had its trailing newline removed.
+func (sdl *Sysdlog) sanitize(m string) string {
+	if sdl.replacer != nil {
+		m = sdl.replacer.Replace(m)
+	}
+
+	return m
+}
+
+// deliver sends a formatted line through each stage of the delivery
+// pipeline in order:
+//
+//  1. the systemd logger, reconnecting once if needed;
+//  2. the retry queue, if one was configured, to be sent again once
+//     the socket recovers;
+//  3. the fallback writer, if one was configured;
+//  4. nowhere, in which case the line is counted as dropped.
+//
+// The first stage to succeed ends the pipeline. Lines pushed out of a
+// full retry queue continue at the fallback stage. The outcome of each
+// stage is counted in Stats. The caller must hold the lock.
+func (sdl *Sysdlog) deliver(s Severity, b []byte) error {
+	// A text sink replaces the whole pipeline.
+	if sdl.textSink != nil {
+		if _, err := sdl.textSink.Write(b); err != nil {
+			sdl.counters.setLastError(err)
+			return err
+		}
+		sdl.counters.bySeverity[s.level()].Add(1)
+		return nil
+	}
+
+	// Severe messages reach stderr first, in case the process dies
+	// before the socket write finishes.
+	if sdl.mirror != nil && s.AtLeast(sdl.mirrorAbove) {
+		if _, err := sdl.mirror.Write(b); err != nil {
+			sdl.counters.setLastError(err)
+		}
+	}
+
+	// A degraded logger leaves the socket to the health checks.
+	if sdl.degraded.Load() {
+		if sdl.retryQueue != nil {
+			return sdl.enqueueRetry(s, b)
+		}
+		return sdl.fallbackOrDrop(s, b, errDegraded)
+	}
+
+	// Queued lines go first so the order is kept. If they can't be
+	// sent, the socket is still down and this line joins them.
+	if sdl.retryQueue != nil && !sdl.drainRetryQueue() {
+		return sdl.enqueueRetry(s, b)
+	}
+
+	err := sdl.sendSocket(s, b)
+	if err == nil {
+		return nil
+	}
+
+	if sdl.retryQueue != nil {
+		return sdl.enqueueRetry(s, b)
+	}
+
+	return sdl.fallbackOrDrop(s, b, err)
+}
+
+// sendSocket is the socket stage of deliver.
+func (sdl *Sysdlog) sendSocket(s Severity, b []byte) error {
+	c := &sdl.counters
+
+	if err := sdl.send(b); err != nil {
+		c.socketFailures.Add(1)
+		c.setLastError(err)
+		return err
+	}
+
+	c.socketWrites.Add(1)
+	c.bySeverity[s.level()].Add(1)
+	return nil
+}
+
+// frame turns the line b into what is written to the socket: the
+// newline a stream needs, then the envelope header, if there is one,
+// with the whole packet fitted to the UDP size limit.
+func (sdl *Sysdlog) frame(b []byte) []byte {
+	// Raw writes may not be terminated, which a stream needs.
+	if sdl.stream && !bytes.HasSuffix(b, []byte("\n")) {
+		b = append(b[:len(b):len(b)], '\n')
+	}
+
+	limit := sdl.packetLimit()
+	if sdl.envelopeHeader == nil {
+		if limit > 0 && len(b) > limit {
+			b = fitPacket(b, limit)
+		}
+		return b
+	}
+
+	h := sdl.envelopeHeader(len(b))
+	if limit > 0 && len(h)+len(b) > limit {
+		b = fitPacket(b, max(limit-len(h), 0))
+		h = sdl.envelopeHeader(len(b))
+	}
+
+	return append(append(make([]byte, 0, len(h)+len(b)), h...), b...)
+}
+
+// fallbackOrDrop is the fallback stage of deliver, and the drop stage
+// if that fails too. err is the error from the socket stage.
+func (sdl *Sysdlog) fallbackOrDrop(s Severity, b []byte, err error) error {
+	c := &sdl.counters
+
+	if sdl.fallback != nil {
+		if _, err = sdl.fallback.Write(b); err == nil {
+			c.fallbackWrites.Add(1)
+			c.bySeverity[s.level()].Add(1)
+			return nil
+		}
+		c.fallbackFailures.Add(1)
+		c.setLastError(err)
+	}
+
+	c.dropped.Add(1)
+	return fmt.Errorf("sysdlog: message dropped: %w", err)
+}
+
+// send writes an already formatted line, reconnecting and trying
+// again once if the write fails. The caller must hold the lock.
+func (sdl *Sysdlog) send(b []byte) error {
+	// The retry budget covers every attempt below. A zero deadline
+	// means there is no limit. The budget is tracked with the
+	// logger's clock, while the socket itself can only be given a
+	// deadline in real time.
+	var start, deadline time.Time
+	if sdl.retryBudget > 0 {
+		start = sdl.now()
+		deadline = time.Now().Add(sdl.retryBudget)
+	}
+	deadline = sdl.closeDeadline(deadline)
+
+	sdl.maybeReresolve()
 
 	// Try a write if we have a connection.
 	if sdl.conn != nil {
-		if n, err := sdl.write(s, m); err == nil {
-			return n, err
+		err := sdl.write(b, deadline)
+		if err == nil {
+			sdl.keepLast(b)
+			return nil
+		}
+
+		if sdl.noReconnect || (!deadline.IsZero() && sdl.since(start) >= sdl.retryBudget) {
+			return err
 		}
 	}
 
+	if sdl.noReconnect {
+		return errNotConnected
+	}
+
 	// If we have no connection or the write above failed, try to
 	// connect again.
-	if err := sdl.connect(); err != nil {
-		return 0, err
+	sdl.waitReconnect(deadline)
+	err := sdl.connect(deadline)
+	sdl.noteReconnect(err)
+	if err != nil {
+		return err
+	}
+	sdl.counters.reconnects.Add(1)
+
+	if err := sdl.resendLast(deadline); err != nil {
+		return err
 	}
 
 	// Try the write again after a reconnect.
-	return sdl.write(s, m)
+	if err := sdl.write(b, deadline); err != nil {
+		return err
+	}
+	sdl.keepLast(b)
+
+	return nil
 }
 
-func (sdl *Sysdlog) write(s Severity, m string) (int, error) {
+// write sends b on the current connection. The write fails if it
+// hasn't completed by the deadline, unless it is zero.
+func (sdl *Sysdlog) write(b []byte, deadline time.Time) error {
+	if err := sdl.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	// Close may have set its deadline on the connection just before
+	// the one above replaced it.
+	if d := sdl.closeDeadline(deadline); !d.Equal(deadline) {
+		if err := sdl.conn.SetWriteDeadline(d); err != nil {
+			return err
+		}
+	}
+
+	b = sdl.frame(b)
+	if sdl.writeHook != nil {
+		sdl.writeHook(b)
+	}
 
-	nl := ""
-	if !strings.HasSuffix(m, "\n") {
-		nl = "\n"
+	var err error
+	if sdl.passCred {
+		err = writeWithCred(sdl.conn, b, sdl.creds)
+	} else {
+		_, err = sdl.conn.Write(b)
+	}
+	if err == nil {
+		sdl.counters.bytesWritten.Add(uint64(len(b)))
 	}
 
-	fmt.Println("prefix:", sdl.prefix)
-	fmt.Fprintf(sdl.conn, "%s %s%s%s", s, sdl.prefix, m, nl)
-	return len(m), nil
+	return err
 }
 
-// connect is a helper function that does the dialing to the logger.
-func (sdl *Sysdlog) connect() error {
-	conn, err := net.Dial("unixgram", "/dev/log")
+// connect is a helper function that does the dialing to the
+// logger. Dialing gives up at the deadline, unless it is zero. Any
+// previous connection is closed once the new one is established.
+func (sdl *Sysdlog) connect(deadline time.Time) error {
+	if err := sdl.checkSocketPerm(); err != nil {
+		return err
+	}
+
+	conn, err := sdl.dial(deadline)
 	if err != nil {
 		return err
 	}
 
+	if err := sdl.setSockopts(conn); err != nil {
+		conn.Close()
+		return err
+	}
+
+	if sdl.conn != nil {
+		sdl.conn.Close()
+	}
 	sdl.conn = conn
+	sdl.liveConn.Store(&conn)
+	sdl.stream = isStream(conn)
+	sdl.resolved()
 
 	return nil
 }
+
+// closeDeadline returns deadline, or the deadline Close has set for
+// sending if that is sooner. A zero deadline means there is no limit.
+func (sdl *Sysdlog) closeDeadline(deadline time.Time) time.Time {
+	n := sdl.closeBy.Load()
+	if n == 0 {
+		return deadline
+	}
+
+	by := time.Unix(0, n)
+	if deadline.IsZero() || by.Before(deadline) {
+		return by
+	}
+
+	return deadline
+}
+
+// setSockopts applies the configured socket options to a freshly
+// dialed connection.
+func (sdl *Sysdlog) setSockopts(conn net.Conn) error {
+	if sdl.sndbuf > 0 {
+		err := setSendBuffer(conn, sdl.sndbuf)
+		if err != nil && !(sdl.ignoreUnsupported && errors.Is(err, errors.ErrUnsupported)) {
+			return fmt.Errorf("sysdlog: setting SO_SNDBUF: %w", err)
+		}
+	}
+
+	if tc, ok := conn.(*net.TCPConn); ok && sdl.keepAlive != 0 {
+		if err := setKeepAlive(tc, sdl.keepAlive); err != nil {
+			return fmt.Errorf("sysdlog: setting TCP keepalive: %w", err)
+		}
+	}
+
+	sdl.passCred = false
+	if sdl.creds != nil {
+		err := setPassCred(conn)
+		switch {
+		case err == nil:
+			sdl.passCred = true
+		case !(sdl.ignoreUnsupported && errors.Is(err, errors.ErrUnsupported)):
+			return fmt.Errorf("sysdlog: setting SO_PASSCRED: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setKeepAlive applies a keepalive period to a TCP connection, or
+// turns keepalive off for a negative period.
+func setKeepAlive(tc *net.TCPConn, d time.Duration) error {
+	if d < 0 {
+		return tc.SetKeepAlive(false)
+	}
+
+	if err := tc.SetKeepAlive(true); err != nil {
+		return err
+	}
+
+	return tc.SetKeepAlivePeriod(d)
+}
@@ -0,0 +1,165 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Priority is a combination of a syslog facility and severity, encoded
+// the same way as the log/syslog package: the facility occupies the
+// high bits and the severity the low three bits. For example,
+// LOG_DAEMON|LOG_WARNING.Priority() logs warnings from a daemon.
+type Priority int
+
+// These are the facilities from log/syslog.
+const (
+	LOG_KERN Priority = iota << 3
+	LOG_USER
+	LOG_MAIL
+	LOG_DAEMON
+	LOG_AUTH
+	LOG_SYSLOG
+	LOG_LPR
+	LOG_NEWS
+	LOG_UUCP
+	LOG_CRON
+	LOG_AUTHPRIV
+	LOG_FTP
+	_ // unused
+	_ // unused
+	_ // unused
+	_ // unused
+	LOG_LOCAL0
+	LOG_LOCAL1
+	LOG_LOCAL2
+	LOG_LOCAL3
+	LOG_LOCAL4
+	LOG_LOCAL5
+	LOG_LOCAL6
+	LOG_LOCAL7
+)
+
+const (
+	severityMask = 0x07
+	facilityMask = 0xf8
+)
+
+// Priority returns the severity as a Priority so it can be combined
+// with a facility.
+func (s Severity) Priority() Priority {
+	return Priority(s.level())
+}
+
+// Facility is a syslog facility such as LOG_DAEMON. Facilities are
+// Priority values with the severity bits clear, so the two types are
+// the same.
+type Facility = Priority
+
+// maxPRI is the largest valid priority, LOG_LOCAL7 with LOG_DEBUG.
+const maxPRI = int(LOG_LOCAL7) | severityMask
+
+// FormatPRI returns the numeric syslog priority for a facility and
+// severity, as written between the angle brackets of "<PRI>". It
+// returns an error if the facility has severity bits set or is past
+// LOG_LOCAL7, or if the severity isn't one of the eight known values.
+func FormatPRI(f Facility, s Severity) (int, error) {
+	if f < 0 || int(f) > int(LOG_LOCAL7) || f&severityMask != 0 {
+		return 0, fmt.Errorf("sysdlog: invalid facility %d", int(f))
+	}
+	if !s.valid() {
+		return 0, fmt.Errorf("sysdlog: invalid severity %q", string(s))
+	}
+
+	return int(f) | s.level(), nil
+}
+
+// ParsePRI splits a numeric syslog priority into its facility and
+// severity. It returns an error if pri is outside 0 to 191.
+func ParsePRI(pri int) (Facility, Severity, error) {
+	if pri < 0 || pri > maxPRI {
+		return 0, "", fmt.Errorf("sysdlog: priority %d out of range 0 to %d", pri, maxPRI)
+	}
+
+	return Facility(pri & facilityMask), severityFromLevel(pri & severityMask), nil
+}
+
+// Dial creates a Sysdlog that mirrors syslog.Dial from the standard
+// library. If network is empty, it connects to the local systemd
+// logger; otherwise it connects to raddr on the given network. The
+// severity in priority is used by Write and the facility is included
+// with every message. Each message is tagged with tag and the process
+// id, which systemd records as the syslog identifier and pid. If tag
+// is empty, os.Args[0] is used.
+func Dial(network, raddr string, priority Priority, tag string, opts ...Option) (*Sysdlog, error) {
+	if priority < 0 || priority > LOG_LOCAL7|LOG_DEBUG.Priority() {
+		return nil, errors.New("sysdlog: invalid priority")
+	}
+
+	if tag == "" {
+		tag = os.Args[0]
+	}
+
+	sdl := newSysdlog()
+	sdl.tag = tag
+	sdl.pid = os.Getpid()
+	sdl.severity = severityFromLevel(int(priority & severityMask))
+	sdl.facility = priority & facilityMask
+	if network != "" {
+		sdl.network = network
+		sdl.raddr = raddr
+	}
+
+	return sdl.open(opts)
+}
+
+// renderSeverity returns the severity prefix for a message, including
+// the facility if one was given to Dial, unless a custom renderer was
+// configured.
+func (sdl *Sysdlog) renderSeverity(s Severity) string {
+	if sdl.severityRenderer != nil {
+		return sdl.severityRenderer(s)
+	}
+
+	if sdl.facility == 0 {
+		return string(s)
+	}
+
+	return fmt.Sprintf("<%d>", int(sdl.facility)|s.level())
+}
+
+// renderTag returns the syslog style "tag[pid]: " identifier, if the
+// logger has a tag.
+func (sdl *Sysdlog) renderTag() string {
+	if sdl.tag == "" {
+		return ""
+	}
+
+	return sdl.tag + "[" + strconv.Itoa(sdl.pid) + "]: "
+}
+
+// WithPID overrides the process id in the "tag[pid]: " identifier
+// written by a logger created with Dial. It defaults to os.Getpid and
+// is useful when logging on behalf of a child process.
+func WithPID(pid int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.pid = pid
+	}
+}
+
+// WithSeverityRenderer replaces how the severity is written at the
+// start of each message, which is "<N>" by default. This is for
+// collectors that expect something else, like a bare number. The
+// renderer must be a pure function of the severity; it is checked
+// once for every severity when the logger is created, and output that
+// contains a newline, carriage return or NUL is rejected.
+func WithSeverityRenderer(f func(s Severity) string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.severityRenderer = f
+	}
+}
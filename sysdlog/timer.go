@@ -0,0 +1,39 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import "time"
+
+// WithDurationFormat sets how Timer renders durations. By default
+// durations use time.Duration's String method, like "1.5s".
+func WithDurationFormat(f func(d time.Duration) string) Option {
+	return func(sdl *Sysdlog) {
+		sdl.durationFormat = f
+	}
+}
+
+// Timer starts timing something and returns a function that stops the
+// timer. Calling the returned function logs "key=<elapsed>" with
+// severity LOG_INFO. It is meant to be deferred:
+//
+//	defer sdl.Timer("db_query")()
+//
+// The elapsed time is measured with the logger's clock.
+func (sdl *Sysdlog) Timer(key string) func() {
+	if sdl == nil {
+		return func() {}
+	}
+
+	start := sdl.now()
+	return func() {
+		d := sdl.since(start)
+
+		render := time.Duration.String
+		if sdl.durationFormat != nil {
+			render = sdl.durationFormat
+		}
+		sdl.writeRetry(LOG_INFO, key+"="+render(d))
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncationMarker is appended to messages that were cut short.
+const truncationMarker = "..."
+
+// defaultUDPMaxPacketSize keeps syslog packets sent over UDP below a
+// typical MTU, as RFC 5426 recommends, so they aren't fragmented.
+const defaultUDPMaxPacketSize = 1400
+
+// WithMaxLen limits each message to n bytes, not counting the
+// severity, prefix or trailing newline. Longer messages are cut at a
+// UTF-8 boundary and end with "..." to show they were truncated; the
+// marker counts toward the limit. Write reports how many bytes of its
+// input were kept and returns io.ErrShortWrite when a message was
+// truncated. A limit of zero, the default, means no limit.
+func WithMaxLen(n int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.maxLen = n
+	}
+}
+
+// WithMessageMaxRunes limits each message to n runes, for receivers
+// that count characters rather than bytes. It works like WithMaxLen,
+// including the "..." marker, and when both are set the one that cuts
+// the message shorter applies. Zero, the default, means no limit.
+func WithMessageMaxRunes(n int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.maxRunes = n
+	}
+}
+
+// truncate applies the length limits to m. It returns the message to
+// send and the number of bytes of m it includes.
+func (sdl *Sysdlog) truncate(m string) (string, int) {
+	body := strings.TrimSuffix(m, "\n")
+
+	keep, marker, cut := len(body), "", false
+	if sdl.maxLen > 0 && len(body) > sdl.maxLen {
+		keep, marker = cutBytes(body, sdl.maxLen)
+		cut = true
+	}
+	if sdl.maxRunes > 0 && utf8.RuneCountInString(body) > sdl.maxRunes {
+		k, mk := cutRunes(body, sdl.maxRunes)
+		if !cut || k+len(mk) < keep+len(marker) {
+			keep, marker, cut = k, mk, true
+		}
+	}
+
+	if !cut {
+		return m, len(m)
+	}
+
+	return body[:keep] + marker, keep
+}
+
+// cutBytes returns how many bytes of body fit in n bytes along with
+// the marker, cutting at a UTF-8 boundary.
+func cutBytes(body string, n int) (int, string) {
+	marker := truncationMarker
+	if n < len(marker) {
+		marker = marker[:n]
+	}
+
+	keep := n - len(marker)
+	for keep > 0 && !utf8.RuneStart(body[keep]) {
+		keep--
+	}
+
+	return keep, marker
+}
+
+// cutRunes returns how many bytes of body hold the first runes that
+// fit in n runes along with the marker.
+func cutRunes(body string, n int) (int, string) {
+	marker := truncationMarker
+	if n < len(marker) {
+		marker = marker[:n]
+	}
+
+	keep, runes := 0, n-len(marker)
+	for ; runes > 0; runes-- {
+		_, size := utf8.DecodeRuneInString(body[keep:])
+		keep += size
+	}
+
+	return keep, marker
+}
+
+// WithUDPMaxPacketSize limits each packet sent with Dial over UDP to
+// n bytes, including the severity, tag, trailing newline and any
+// WithEnvelopeHeader header. Longer packets are cut at a UTF-8
+// boundary and end with "..." before the newline. Unlike WithMaxLen
+// this is a limit of the transport, so Write still reports the whole
+// message as written. The default is 1400 bytes; zero or less means no
+// limit. It has no effect on unix sockets, whose limit is set by the
+// socket's buffer size.
+func WithUDPMaxPacketSize(n int) Option {
+	return func(sdl *Sysdlog) {
+		sdl.udpMaxPacket = n
+	}
+}
+
+// packetLimit returns the UDP packet size limit, or zero if there is
+// none or the logger isn't sending over UDP.
+func (sdl *Sysdlog) packetLimit() int {
+	if !strings.HasPrefix(sdl.network, "udp") || sdl.udpMaxPacket <= 0 {
+		return 0
+	}
+
+	return sdl.udpMaxPacket
+}
+
+// fitPacket truncates b to n bytes, keeping its newline.
+func fitPacket(b []byte, n int) []byte {
+	if len(b) <= n {
+		return b
+	}
+
+	body, nl := bytes.CutSuffix(b, []byte("\n"))
+	end := truncationMarker
+	if nl {
+		end += "\n"
+	}
+	if n < len(end) {
+		end = end[:n]
+	}
+
+	keep := n - len(end)
+	for keep > 0 && !utf8.RuneStart(body[keep]) {
+		keep--
+	}
+
+	return append(body[:keep:keep], end...)
+}
@@ -0,0 +1,139 @@
+// Copyright 2013 Joshua Marsh <joshua@themarshians.com>. All rights
+// reserved. Use of this source code is governed by the MIT license
+// which can be found in the LICENSE file.
+
+package sysdlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Validate checks the logger's configuration for invalid values and
+// options that contradict each other. New and Dial call it before
+// connecting, so it only needs to be called directly after changing
+// settings at runtime. All problems found are reported together.
+func (sdl *Sysdlog) Validate() error {
+	if sdl == nil {
+		return ErrNilLogger
+	}
+
+	sdl.mu.Lock()
+	defer sdl.mu.Unlock()
+
+	return sdl.validate()
+}
+
+// validate does the work of Validate. The caller must hold the lock,
+// or be the constructor.
+func (sdl *Sysdlog) validate() error {
+	var errs []error
+	add := func(format string, v ...interface{}) {
+		errs = append(errs, fmt.Errorf("sysdlog: "+format, v...))
+	}
+
+	if !sdl.severity.valid() {
+		add("invalid default severity %q", sdl.severity)
+	}
+	if sdl.minSeverity != "" && !sdl.minSeverity.valid() {
+		add("invalid minimum severity %q", sdl.minSeverity)
+	}
+	if sdl.ceiling != "" && !sdl.ceiling.valid() {
+		add("invalid severity ceiling %q", sdl.ceiling)
+	}
+	if sdl.mirror != nil && !sdl.mirrorAbove.valid() {
+		add("invalid stderr mirror severity %q", sdl.mirrorAbove)
+	}
+	for from, to := range sdl.remap {
+		if !from.valid() || !to.valid() {
+			add("invalid severity remap %q to %q", from, to)
+		}
+	}
+	if sdl.severityRenderer != nil {
+		for l := 0; l <= 7; l++ {
+			s := severityFromLevel(l)
+			if r := sdl.severityRenderer(s); strings.ContainsAny(r, "\n\r\x00") {
+				add("severity renderer output %q for %q would break framing", r, s)
+			}
+		}
+	}
+	if sdl.clock == nil {
+		add("clock must not be nil")
+	}
+	if sdl.rand == nil {
+		add("random source must not be nil")
+	}
+	if sdl.sndbuf < 0 {
+		add("send buffer size must not be negative, got %d", sdl.sndbuf)
+	}
+	if sdl.retryBudget < 0 {
+		add("retry budget must not be negative, got %v", sdl.retryBudget)
+	}
+	if sdl.reresolve < 0 {
+		add("reresolve interval must not be negative, got %v", sdl.reresolve)
+	}
+	if sdl.maxLen < 0 {
+		add("max length must not be negative, got %d", sdl.maxLen)
+	}
+	if sdl.maxRunes < 0 {
+		add("max runes must not be negative, got %d", sdl.maxRunes)
+	}
+	if sdl.healInterval > 0 && sdl.healThreshold <= 0 {
+		add("self healing threshold must be positive, got %d", sdl.healThreshold)
+	}
+	if strings.ContainsAny(sdl.instanceID, " \t\r\n") {
+		add("instance id %q must not contain whitespace", sdl.instanceID)
+	}
+	if strings.ContainsAny(sdl.namespace, "/\x00") {
+		add("invalid journal namespace %q", sdl.namespace)
+	}
+	if sdl.spool != nil && (sdl.spool.dir == "" || sdl.spool.max < 0) {
+		add("spool dir must not be empty and max bytes must not be negative, got %q and %d", sdl.spool.dir, sdl.spool.max)
+	}
+	if sdl.spool != nil && sdl.fallback != io.Writer(sdl.spool) {
+		add("spool dir conflicts with a fallback writer")
+	}
+	if sdl.coalesce != nil && sdl.coalesce.window <= 0 {
+		add("coalesce window must be positive, got %v", sdl.coalesce.window)
+	}
+	if sdl.coalesce != nil && sdl.joinSplits() {
+		add("coalescing needs a datagram socket and no fallback writer, unless newlines are replaced")
+	}
+	if sdl.closeTimeout < 0 {
+		add("close timeout must not be negative, got %v", sdl.closeTimeout)
+	}
+	if sdl.reconnectDelay < 0 {
+		add("reconnect delay must not be negative, got %v", sdl.reconnectDelay)
+	}
+	if sdl.reconnectJitter < 0 || sdl.reconnectJitter > 1 {
+		add("reconnect jitter must be between 0 and 1, got %v", sdl.reconnectJitter)
+	}
+	if sdl.dedupe != nil && (sdl.dedupe.window <= 0 || sdl.dedupe.max <= 0) {
+		add("dedupe window and max keys must be positive, got %v and %d", sdl.dedupe.window, sdl.dedupe.max)
+	}
+	if sdl.maxPrefixLen < 0 {
+		add("max prefix length must not be negative, got %d", sdl.maxPrefixLen)
+	}
+	if sdl.ring != nil && len(sdl.ring.entries) == 0 {
+		add("ring buffer size must be positive")
+	}
+	if sdl.retryQueue != nil && sdl.retryQueue.size <= 0 {
+		add("retry queue size must be positive, got %d", sdl.retryQueue.size)
+	}
+	if sdl.newAttempts < 0 || sdl.newDelay < 0 {
+		add("connect retry attempts and delay must not be negative, got %d and %v", sdl.newAttempts, sdl.newDelay)
+	}
+	if sdl.limiter != nil && (sdl.limiter.rate <= 0 || sdl.limiter.burst < 1) {
+		add("rate limit and burst must be positive, got %v and %v", sdl.limiter.rate, sdl.limiter.burst)
+	}
+	if sdl.namespace != "" && (sdl.network != "unixgram" || (sdl.raddr != "/dev/log" && sdl.raddr != sdl.namespaceSocket())) {
+		add("journal namespace %q conflicts with connecting to %s %q", sdl.namespace, sdl.network, sdl.raddr)
+	}
+	if sdl.reresolve > 0 && !sdl.isUnixNetwork() {
+		add("reresolve interval only applies to unix sockets, not %q", sdl.network)
+	}
+
+	return errors.Join(errs...)
+}